@@ -5,6 +5,7 @@ import (
 	"go.uber.org/zap"
 	"go_store/config"
 	"go_store/internal/app"
+	"go_store/internal/logging"
 	"log"
 )
 
@@ -22,7 +23,7 @@ func main() {
 
 	var logger *zap.Logger
 
-	logger, err = zap.NewProduction()
+	logger, err = zap.NewProduction(zap.WrapCore(logging.Redact))
 
 	if err != nil {
 		log.Fatalf("can not initialize logger: %s", err)
@@ -4,19 +4,57 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultJWTIssuer       = "go_store"
+	defaultAdminAudience   = "go_store_admin"
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultDenylistRefresh = 10 * time.Second
+
+	defaultCustomerAudience       = "go_store_customer"
+	defaultCustomerAccessTokenTTL = 24 * time.Hour
+	defaultEmailVerificationTTL   = 48 * time.Hour
+	defaultPasswordResetTTL       = time.Hour
+	defaultStoreURL               = "http://localhost:3000"
+
+	defaultSMTPPort = "587"
+
+	defaultLoginMaxFailures      = 5
+	defaultLoginMaxFailuresPerIP = 20
+	defaultLoginLockout          = 30 * time.Second
+	defaultLoginMaxLockout       = time.Hour
+
+	defaultEmailMaxSends      = 3
+	defaultEmailMaxSendsPerIP = 10
+	defaultEmailLockout       = 5 * time.Minute
+	defaultEmailMaxLockout    = time.Hour
 )
 
 type (
 	Config struct {
 		GRPC
+		HTTP
 		PG
 		Admin
+		Customer
+		Mail
+		Payment
 	}
 
 	GRPC struct {
 		Port string `env:"GRPC_PORT"`
 	}
 
+	HTTP struct {
+		// Port of the HTTP server with the JWKS endpoint. The server is not started if it is empty.
+		Port string `env:"HTTP_PORT"`
+	}
+
 	PG struct {
 		URL      string
 		Host     string `env:"POSTGRES_HOST"`
@@ -30,13 +68,87 @@ type (
 		Username     string `env:"ADMIN_USERNAME"`
 		PasswordHash string `env:"ADMIN_PASSWORD_HASH"`
 		JWTSecret    string `env:"ADMIN_JWT_SECRET"`
+		// JWTSigningKeyFile is a PEM file with an RSA or Ed25519 private key. If set, tokens are signed
+		// with it instead of JWTSecret.
+		JWTSigningKeyFile string `env:"ADMIN_JWT_SIGNING_KEY_FILE"`
+		JWTSigningKeyID   string `env:"ADMIN_JWT_SIGNING_KEY_ID"`
+		// JWTAcceptLegacyHS256Until keeps tokens without kid, signed with JWTSecret before the signing key
+		// file was configured, valid if they were issued before this time. Zero means they are rejected.
+		JWTAcceptLegacyHS256Until time.Time `env:"ADMIN_JWT_ACCEPT_LEGACY_HS256_UNTIL"`
+		// JWTVerificationKeyFiles maps key IDs to PEM files with public keys of previous signing keys.
+		JWTVerificationKeyFiles map[string]string `env:"ADMIN_JWT_VERIFICATION_KEY_FILES"`
+		JWTIssuer               string            `env:"JWT_ISSUER"`
+		JWTAudience             string            `env:"ADMIN_JWT_AUDIENCE"`
+		AccessTokenTTL          time.Duration     `env:"ADMIN_ACCESS_TOKEN_TTL"`
+		RefreshTokenTTL         time.Duration     `env:"ADMIN_REFRESH_TOKEN_TTL"`
+		DenylistRefresh         time.Duration     `env:"ADMIN_DENYLIST_REFRESH_INTERVAL"`
+		Login                   Login
 	}
+
+	// Customer configures customer access tokens. They are signed with the same keys as administrator tokens
+	// but have a different audience, so one can not be used in place of the other.
+	Customer struct {
+		JWTIssuer      string        `env:"JWT_ISSUER"`
+		JWTAudience    string        `env:"CUSTOMER_JWT_AUDIENCE"`
+		AccessTokenTTL time.Duration `env:"CUSTOMER_ACCESS_TOKEN_TTL"`
+		// EmailVerificationTTL and PasswordResetTTL are lifetimes of the single-use tokens sent by email.
+		EmailVerificationTTL time.Duration `env:"CUSTOMER_EMAIL_VERIFICATION_TTL"`
+		PasswordResetTTL     time.Duration `env:"CUSTOMER_PASSWORD_RESET_TTL"`
+		// StoreURL is the storefront address used in links sent to customers.
+		StoreURL string `env:"STORE_URL"`
+		// Login configures the lockout after failed customer logins, read from CUSTOMER_LOGIN_* variables.
+		Login Login
+		// Email limits verification and password reset emails, read from CUSTOMER_EMAIL_* variables.
+		// Every email sent counts as a failure.
+		Email Login
+	}
+
+	// Mail configures how emails to customers are delivered.
+	Mail struct {
+		// Driver is one of MailDriverSMTP, MailDriverFile or MailDriverLog.
+		Driver       string `env:"MAIL_DRIVER"`
+		From         string `env:"MAIL_FROM"`
+		SMTPHost     string `env:"SMTP_HOST"`
+		SMTPPort     string `env:"SMTP_PORT"`
+		SMTPUsername string `env:"SMTP_USERNAME"`
+		SMTPPassword string `env:"SMTP_PASSWORD"`
+		// File receives messages of the file driver.
+		File string `env:"MAIL_FILE"`
+	}
+
+	// Payment configures the payment provider.
+	Payment struct {
+		// Provider is PaymentProviderFake, the only provider so far.
+		Provider string `env:"PAYMENT_PROVIDER"`
+		// WebhookSecret signs callbacks of the provider. Callbacks are rejected if it is empty.
+		WebhookSecret string `env:"PAYMENT_WEBHOOK_SECRET"`
+	}
+
+	// Login configures the lockout after failed login attempts.
+	Login struct {
+		// MaxFailures and MaxFailuresPerIP are the number of failures before the first lockout
+		// of a username and of a client address.
+		MaxFailures      int           `env:"LOGIN_MAX_FAILURES"`
+		MaxFailuresPerIP int           `env:"LOGIN_MAX_FAILURES_PER_IP"`
+		Lockout          time.Duration `env:"LOGIN_LOCKOUT"`
+		MaxLockout       time.Duration `env:"LOGIN_MAX_LOCKOUT"`
+	}
+)
+
+const (
+	MailDriverSMTP = "smtp"
+	MailDriverFile = "file"
+	MailDriverLog  = "log"
 )
 
+// PaymentProviderFake is the in-process provider for development and tests.
+const PaymentProviderFake = "fake"
+
 func New() (*Config, error) {
 	cfg := &Config{}
 
 	cfg.GRPC.Port = os.Getenv("GRPC_PORT")
+	cfg.HTTP.Port = os.Getenv("HTTP_PORT")
 
 	cfg.PG.Host = os.Getenv("POSTGRES_HOST")
 	cfg.PG.Port = os.Getenv("POSTGRES_PORT")
@@ -47,6 +159,106 @@ func New() (*Config, error) {
 	cfg.Admin.Username = os.Getenv("ADMIN_USERNAME")
 	cfg.Admin.PasswordHash = os.Getenv("ADMIN_PASSWORD_HASH")
 	cfg.Admin.JWTSecret = os.Getenv("ADMIN_JWT_SECRET")
+	cfg.Admin.JWTSigningKeyFile = os.Getenv("ADMIN_JWT_SIGNING_KEY_FILE")
+	cfg.Admin.JWTSigningKeyID = os.Getenv("ADMIN_JWT_SIGNING_KEY_ID")
+	cfg.Admin.JWTIssuer = stringEnv("JWT_ISSUER", defaultJWTIssuer)
+	cfg.Admin.JWTAudience = stringEnv("ADMIN_JWT_AUDIENCE", defaultAdminAudience)
+	cfg.Customer.JWTIssuer = cfg.Admin.JWTIssuer
+	cfg.Customer.JWTAudience = stringEnv("CUSTOMER_JWT_AUDIENCE", defaultCustomerAudience)
+	cfg.Customer.StoreURL = strings.TrimSuffix(stringEnv("STORE_URL", defaultStoreURL), "/")
+
+	cfg.Mail.Driver = os.Getenv("MAIL_DRIVER")
+	cfg.Mail.From = os.Getenv("MAIL_FROM")
+	cfg.Mail.SMTPHost = os.Getenv("SMTP_HOST")
+	cfg.Mail.SMTPPort = stringEnv("SMTP_PORT", defaultSMTPPort)
+	cfg.Mail.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.Mail.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	cfg.Mail.File = os.Getenv("MAIL_FILE")
+
+	cfg.Payment.Provider = os.Getenv("PAYMENT_PROVIDER")
+	cfg.Payment.WebhookSecret = os.Getenv("PAYMENT_WEBHOOK_SECRET")
+
+	var err error
+	if cfg.Admin.JWTVerificationKeyFiles, err = mapEnv("ADMIN_JWT_VERIFICATION_KEY_FILES"); err != nil {
+		return nil, err
+	}
+	if cfg.Admin.JWTAcceptLegacyHS256Until, err = timeEnv("ADMIN_JWT_ACCEPT_LEGACY_HS256_UNTIL"); err != nil {
+		return nil, err
+	}
+	if cfg.Admin.AccessTokenTTL, err = durationEnv("ADMIN_ACCESS_TOKEN_TTL", defaultAccessTokenTTL); err != nil {
+		return nil, err
+	}
+	if cfg.Admin.RefreshTokenTTL, err = durationEnv("ADMIN_REFRESH_TOKEN_TTL", defaultRefreshTokenTTL); err != nil {
+		return nil, err
+	}
+	if cfg.Admin.DenylistRefresh, err = durationEnv("ADMIN_DENYLIST_REFRESH_INTERVAL", defaultDenylistRefresh); err != nil {
+		return nil, err
+	}
+	if cfg.Customer.AccessTokenTTL, err = durationEnv("CUSTOMER_ACCESS_TOKEN_TTL", defaultCustomerAccessTokenTTL); err != nil {
+		return nil, err
+	}
+	if cfg.Customer.EmailVerificationTTL, err = durationEnv("CUSTOMER_EMAIL_VERIFICATION_TTL", defaultEmailVerificationTTL); err != nil {
+		return nil, err
+	}
+	if cfg.Customer.PasswordResetTTL, err = durationEnv("CUSTOMER_PASSWORD_RESET_TTL", defaultPasswordResetTTL); err != nil {
+		return nil, err
+	}
+	loginDefaults := Login{
+		MaxFailures:      defaultLoginMaxFailures,
+		MaxFailuresPerIP: defaultLoginMaxFailuresPerIP,
+		Lockout:          defaultLoginLockout,
+		MaxLockout:       defaultLoginMaxLockout,
+	}
+	if cfg.Admin.Login, err = loginEnv("LOGIN_", loginDefaults); err != nil {
+		return nil, err
+	}
+	if cfg.Customer.Login, err = loginEnv("CUSTOMER_LOGIN_", loginDefaults); err != nil {
+		return nil, err
+	}
+	cfg.Customer.Email, err = loginEnv("CUSTOMER_EMAIL_", Login{
+		MaxFailures:      defaultEmailMaxSends,
+		MaxFailuresPerIP: defaultEmailMaxSendsPerIP,
+		Lockout:          defaultEmailLockout,
+		MaxLockout:       defaultEmailMaxLockout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Mail.Driver {
+	case MailDriverSMTP:
+		if cfg.Mail.SMTPHost == "" || cfg.Mail.From == "" {
+			return nil, fmt.Errorf("SMTP_HOST and MAIL_FROM must be set for MAIL_DRIVER=%s", MailDriverSMTP)
+		}
+	case MailDriverFile:
+		if cfg.Mail.File == "" {
+			return nil, fmt.Errorf("MAIL_FILE must be set for MAIL_DRIVER=%s", MailDriverFile)
+		}
+	case MailDriverLog:
+	case "":
+		return nil, fmt.Errorf("MAIL_DRIVER must be set to %s, %s or %s", MailDriverSMTP, MailDriverFile, MailDriverLog)
+	default:
+		return nil, fmt.Errorf("MAIL_DRIVER: unknown driver %q", cfg.Mail.Driver)
+	}
+
+	// Administrator and customer tokens are signed with the same keys, only the audience tells them apart.
+	if cfg.Admin.JWTAudience == cfg.Customer.JWTAudience {
+		return nil, fmt.Errorf("ADMIN_JWT_AUDIENCE and CUSTOMER_JWT_AUDIENCE must differ, both are %q", cfg.Admin.JWTAudience)
+	}
+	// Tokens sent by email use CUSTOMER_JWT_AUDIENCE followed by "/" and the purpose.
+	if strings.HasPrefix(cfg.Admin.JWTAudience, cfg.Customer.JWTAudience+"/") {
+		return nil, fmt.Errorf("ADMIN_JWT_AUDIENCE %q must not start with CUSTOMER_JWT_AUDIENCE followed by \"/\"",
+			cfg.Admin.JWTAudience)
+	}
+
+	// There is no default, so that a production deployment can not end up with the fake provider by mistake.
+	switch cfg.Payment.Provider {
+	case PaymentProviderFake:
+	case "":
+		return nil, fmt.Errorf("PAYMENT_PROVIDER must be set to %s", PaymentProviderFake)
+	default:
+		return nil, fmt.Errorf("PAYMENT_PROVIDER: unknown provider %q", cfg.Payment.Provider)
+	}
 
 	cfg.PG.URL = fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable",
 		cfg.PG.User,
@@ -57,3 +269,93 @@ func New() (*Config, error) {
 
 	return cfg, nil
 }
+
+// stringEnv returns the value of the environment variable, or def if it is not set.
+func stringEnv(name string, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// durationEnv parses the environment variable as time.Duration (e.g. "15m"), or returns def if it is not set.
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive", name)
+	}
+	return d, nil
+}
+
+// timeEnv parses the environment variable as an RFC 3339 time, or returns the zero time if it is not set.
+func timeEnv(name string) (time.Time, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return t, nil
+}
+
+// intEnv parses the environment variable as a positive integer, or returns def if it is not set.
+func intEnv(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%s must be positive", name)
+	}
+	return n, nil
+}
+
+// loginEnv reads a lockout policy from the MAX_FAILURES, MAX_FAILURES_PER_IP, LOCKOUT and MAX_LOCKOUT
+// variables with the prefix, taking unset ones from def.
+func loginEnv(prefix string, def Login) (Login, error) {
+	var login Login
+	var err error
+	if login.MaxFailures, err = intEnv(prefix+"MAX_FAILURES", def.MaxFailures); err != nil {
+		return login, err
+	}
+	if login.MaxFailuresPerIP, err = intEnv(prefix+"MAX_FAILURES_PER_IP", def.MaxFailuresPerIP); err != nil {
+		return login, err
+	}
+	if login.Lockout, err = durationEnv(prefix+"LOCKOUT", def.Lockout); err != nil {
+		return login, err
+	}
+	if login.MaxLockout, err = durationEnv(prefix+"MAX_LOCKOUT", def.MaxLockout); err != nil {
+		return login, err
+	}
+	return login, nil
+}
+
+// mapEnv parses the environment variable as a comma-separated list of key=value pairs.
+func mapEnv(name string) (map[string]string, error) {
+	result := map[string]string{}
+	value := os.Getenv(name)
+	if value == "" {
+		return result, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" || val == "" {
+			return nil, fmt.Errorf("%s: expected key=value, got %q", name, pair)
+		}
+		result[key] = val
+	}
+	return result, nil
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE product
+    ADD COLUMN stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0);
+
+-- Products created before stock was tracked get INITIAL_PRODUCT_STOCK units, so they can still be ordered
+-- until their real stock is set. If there are such products and the variable is not set, the update
+-- violates NOT NULL and the migration fails instead of making the whole catalog unorderable.
+-- +goose ENVSUB ON
+UPDATE product
+SET stock = NULLIF('${INITIAL_PRODUCT_STOCK:-}', '')::int;
+-- +goose ENVSUB OFF
+
+-- +goose Down
+ALTER TABLE product
+    DROP COLUMN stock;
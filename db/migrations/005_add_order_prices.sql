@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE order_item
+    ADD COLUMN unit_price BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN line_total BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    ADD COLUMN subtotal BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN total    BIGINT NOT NULL DEFAULT 0;
+
+-- Existing orders have no snapshot, so the current product price is the best estimate.
+UPDATE order_item oi
+SET unit_price = p.price,
+    line_total = p.price * oi.quantity
+FROM product p
+WHERE p.id = oi.product_id;
+
+UPDATE orders o
+SET subtotal = t.sum,
+    total    = t.sum
+FROM (SELECT order_id, SUM(line_total) AS sum FROM order_item GROUP BY order_id) t
+WHERE t.order_id = o.id;
+
+-- +goose Down
+ALTER TABLE orders
+    DROP COLUMN total,
+    DROP COLUMN subtotal;
+
+ALTER TABLE order_item
+    DROP COLUMN line_total,
+    DROP COLUMN unit_price;
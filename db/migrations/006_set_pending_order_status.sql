@@ -0,0 +1,8 @@
+-- +goose Up
+-- Orders used to be created without a status; they are waiting to be processed.
+UPDATE orders
+SET status = 1
+WHERE status = 0;
+
+-- +goose Down
+SELECT 1;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE order_status_history
+(
+    id         UUID PRIMARY KEY      DEFAULT uuid_generate_v4(),
+    order_id   UUID         NOT NULL,
+    old_status INT          NOT NULL,
+    new_status INT          NOT NULL,
+    changed_by VARCHAR(255) NOT NULL,
+    reason     TEXT         NOT NULL DEFAULT '',
+    changed_at TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id, changed_at);
+
+-- Existing orders start their history with their current status. For pending orders this is the creation;
+-- the earlier changes of the others are unknown, so their row is marked as migrated.
+INSERT INTO order_status_history (order_id, old_status, new_status, changed_by, reason, changed_at)
+SELECT id,
+       0,
+       status,
+       '',
+       CASE WHEN status = 1 THEN '' ELSE 'status when the history was introduced' END,
+       COALESCE(CASE WHEN status = 1 THEN created_at ELSE updated_at END, CURRENT_TIMESTAMP)
+FROM orders;
+
+-- +goose Down
+DROP TABLE order_status_history;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE product
+    ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE INDEX product_active_name_idx ON product (name) WHERE archived_at IS NULL;
+
+-- Products referenced by orders are archived instead of being deleted.
+ALTER TABLE order_item
+    DROP CONSTRAINT order_item_product_id_fkey,
+    ADD CONSTRAINT order_item_product_id_fkey
+        FOREIGN KEY (product_id) REFERENCES product (id) ON DELETE RESTRICT;
+
+-- +goose Down
+ALTER TABLE order_item
+    DROP CONSTRAINT order_item_product_id_fkey,
+    ADD CONSTRAINT order_item_product_id_fkey
+        FOREIGN KEY (product_id) REFERENCES product (id) ON DELETE CASCADE;
+
+DROP INDEX product_active_name_idx;
+
+ALTER TABLE product
+    DROP COLUMN archived_at;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE category
+(
+    id          UUID PRIMARY KEY      DEFAULT uuid_generate_v4(),
+    parent_id   UUID,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    FOREIGN KEY (parent_id) REFERENCES category (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX category_parent_id_idx ON category (parent_id);
+
+CREATE TABLE product_category
+(
+    product_id  UUID NOT NULL,
+    category_id UUID NOT NULL,
+    PRIMARY KEY (product_id, category_id),
+    FOREIGN KEY (product_id) REFERENCES product (id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES category (id) ON DELETE CASCADE
+);
+
+CREATE INDEX product_category_category_id_idx ON product_category (category_id);
+
+-- +goose Down
+DROP TABLE product_category;
+DROP TABLE category;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- The russian configuration stems Cyrillic words with the russian stemmer and Latin words with the english one.
+ALTER TABLE product
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(description, '')), 'B')
+        ) STORED;
+
+CREATE INDEX product_search_vector_idx ON product USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX product_search_vector_idx;
+
+ALTER TABLE product
+    DROP COLUMN search_vector;
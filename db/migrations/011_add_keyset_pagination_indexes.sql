@@ -0,0 +1,11 @@
+-- +goose Up
+DROP INDEX product_active_name_idx;
+CREATE INDEX product_active_name_idx ON product (name, id) WHERE archived_at IS NULL;
+
+CREATE INDEX orders_created_at_idx ON orders (created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX orders_created_at_idx;
+
+DROP INDEX product_active_name_idx;
+CREATE INDEX product_active_name_idx ON product (name) WHERE archived_at IS NULL;
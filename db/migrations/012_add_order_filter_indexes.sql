@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE INDEX orders_updated_at_idx ON orders (updated_at DESC, id DESC);
+CREATE INDEX orders_total_idx ON orders (total DESC, id DESC);
+CREATE INDEX orders_status_idx ON orders (status);
+CREATE INDEX orders_customer_email_idx ON orders (lower(customer_email));
+CREATE INDEX order_item_product_id_idx ON order_item (product_id);
+
+-- +goose Down
+DROP INDEX order_item_product_id_idx;
+DROP INDEX orders_customer_email_idx;
+DROP INDEX orders_status_idx;
+DROP INDEX orders_total_idx;
+DROP INDEX orders_updated_at_idx;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE admin_user
+(
+    id            UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    username      VARCHAR(64) NOT NULL UNIQUE,
+    password_hash TEXT        NOT NULL,
+    roles         TEXT[]      NOT NULL CHECK (roles <@ ARRAY ['superuser', 'catalog_manager', 'order_manager']),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    disabled_at   TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE admin_user;
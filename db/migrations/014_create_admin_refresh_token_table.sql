@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE admin_refresh_token
+(
+    id         UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    admin_id   UUID        NOT NULL,
+    -- All tokens issued by rotating the same login share the family.
+    family_id  UUID        NOT NULL,
+    token_hash TEXT        NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (admin_id) REFERENCES admin_user (id) ON DELETE CASCADE
+);
+
+CREATE INDEX admin_refresh_token_admin_id_idx ON admin_refresh_token (admin_id);
+CREATE INDEX admin_refresh_token_family_id_idx ON admin_refresh_token (family_id);
+
+-- +goose Down
+DROP TABLE admin_refresh_token;
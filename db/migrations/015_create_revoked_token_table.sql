@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE revoked_token
+(
+    -- jti of the revoked access token.
+    id         TEXT PRIMARY KEY,
+    -- The row can be removed once the token has expired.
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX revoked_token_expires_at_idx ON revoked_token (expires_at);
+
+-- Access tokens issued to the administrator before this moment are revoked.
+ALTER TABLE admin_user
+    ADD COLUMN sessions_revoked_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE admin_user
+    DROP COLUMN sessions_revoked_at;
+
+DROP TABLE revoked_token;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE login_attempt
+(
+    -- Throttled subject, e.g. "admin:user:<username>" or "admin:ip:<address>".
+    key             TEXT PRIMARY KEY,
+    failures        INT         NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until    TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE login_attempt;
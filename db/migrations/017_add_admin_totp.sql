@@ -0,0 +1,35 @@
+-- +goose Up
+ALTER TABLE admin_user
+    -- Set on enrollment, two-factor authentication is on only after confirmation.
+    ADD COLUMN totp_secret     TEXT,
+    ADD COLUMN totp_enabled_at TIMESTAMPTZ,
+    -- The last accepted period, so that a code can not be used twice.
+    ADD COLUMN totp_last_step  BIGINT;
+
+CREATE TABLE admin_recovery_code
+(
+    admin_id  UUID NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at   TIMESTAMPTZ,
+    PRIMARY KEY (admin_id, code_hash),
+    FOREIGN KEY (admin_id) REFERENCES admin_user (id) ON DELETE CASCADE
+);
+
+CREATE TABLE admin_login_challenge
+(
+    id         UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    admin_id   UUID        NOT NULL,
+    token_hash TEXT        NOT NULL UNIQUE,
+    attempts   INT         NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (admin_id) REFERENCES admin_user (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE admin_login_challenge;
+DROP TABLE admin_recovery_code;
+
+ALTER TABLE admin_user
+    DROP COLUMN totp_last_step,
+    DROP COLUMN totp_enabled_at,
+    DROP COLUMN totp_secret;
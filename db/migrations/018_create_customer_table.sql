@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE customer
+(
+    id            UUID PRIMARY KEY      DEFAULT uuid_generate_v4(),
+    email         VARCHAR(255) NOT NULL,
+    password_hash TEXT         NOT NULL,
+    name          VARCHAR(255) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX customer_email_idx ON customer (lower(email));
+
+ALTER TABLE orders
+    ADD COLUMN customer_id UUID REFERENCES customer (id);
+
+CREATE INDEX orders_customer_id_idx ON orders (customer_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX orders_customer_id_idx;
+ALTER TABLE orders
+    DROP COLUMN customer_id;
+DROP TABLE customer;
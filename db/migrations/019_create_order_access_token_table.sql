@@ -0,0 +1,15 @@
+-- +goose Up
+-- Secrets returned when an order is placed. Guests can access their orders only with one of them,
+-- so guest orders placed before this table existed are visible to administrators only.
+CREATE TABLE order_access_token
+(
+    token_hash TEXT PRIMARY KEY,
+    order_id   UUID        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX order_access_token_order_id_idx ON order_access_token (order_id);
+
+-- +goose Down
+DROP TABLE order_access_token;
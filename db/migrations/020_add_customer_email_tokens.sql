@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE customer
+    ADD COLUMN email_verified_at   TIMESTAMPTZ,
+    -- Access tokens issued before this time are rejected.
+    ADD COLUMN sessions_revoked_at TIMESTAMPTZ;
+
+-- Tokens sent by email. The token itself is a signed JWT, only its ID is stored to make it single-use.
+CREATE TABLE customer_email_token
+(
+    id          TEXT PRIMARY KEY,
+    customer_id UUID        NOT NULL,
+    purpose     TEXT        NOT NULL CHECK (purpose IN ('verify_email', 'reset_password')),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    FOREIGN KEY (customer_id) REFERENCES customer (id) ON DELETE CASCADE
+);
+
+CREATE INDEX customer_email_token_customer_id_idx ON customer_email_token (customer_id, purpose);
+
+-- +goose Down
+DROP TABLE customer_email_token;
+
+ALTER TABLE customer
+    DROP COLUMN sessions_revoked_at,
+    DROP COLUMN email_verified_at;
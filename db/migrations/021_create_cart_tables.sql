@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE cart
+(
+    id          UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    -- NULL for guest carts. A customer has at most one cart.
+    customer_id UUID UNIQUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    FOREIGN KEY (customer_id) REFERENCES customer (id) ON DELETE CASCADE
+);
+
+CREATE TABLE cart_item
+(
+    cart_id    UUID        NOT NULL,
+    product_id UUID        NOT NULL,
+    quantity   INT         NOT NULL CHECK (quantity > 0),
+    added_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (cart_id, product_id),
+    FOREIGN KEY (cart_id) REFERENCES cart (id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES product (id)
+);
+
+-- +goose Down
+DROP TABLE cart_item;
+DROP TABLE cart;
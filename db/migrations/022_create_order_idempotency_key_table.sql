@@ -0,0 +1,20 @@
+-- +goose Up
+-- Keys sent with CreateOrder. A retried request with the same key and payload returns the order
+-- created by the first one instead of placing a new order. Keys are unique per scope, the customer
+-- or the email of a guest, and expire after a day.
+CREATE TABLE order_idempotency_key
+(
+    scope        TEXT        NOT NULL,
+    key          TEXT        NOT NULL,
+    request_hash TEXT        NOT NULL,
+    order_id     UUID        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (scope, key),
+    FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX order_idempotency_key_order_id_idx ON order_idempotency_key (order_id);
+CREATE INDEX order_idempotency_key_created_at_idx ON order_idempotency_key (created_at);
+
+-- +goose Down
+DROP TABLE order_idempotency_key;
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE coupon
+(
+    id                    UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    code                  VARCHAR(64) NOT NULL,
+    type                  TEXT        NOT NULL CHECK (type IN ('percentage', 'fixed_amount', 'free_item')),
+    -- Percent for percentage coupons, amount for fixed_amount coupons.
+    value                 BIGINT      NOT NULL DEFAULT 0 CHECK (value >= 0),
+    free_product_id       UUID REFERENCES product (id),
+    min_order_value       BIGINT      NOT NULL DEFAULT 0 CHECK (min_order_value >= 0),
+    starts_at             TIMESTAMPTZ,
+    ends_at               TIMESTAMPTZ,
+    -- Zero means unlimited.
+    max_uses              INTEGER     NOT NULL DEFAULT 0 CHECK (max_uses >= 0),
+    max_uses_per_customer INTEGER     NOT NULL DEFAULT 0 CHECK (max_uses_per_customer >= 0),
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    disabled_at           TIMESTAMPTZ,
+    CHECK (type <> 'free_item' OR free_product_id IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX coupon_code_idx ON coupon (lower(code));
+
+-- The code is copied to the order, so it is still shown if the coupon is changed later.
+ALTER TABLE orders
+    ADD COLUMN coupon_id   UUID REFERENCES coupon (id),
+    ADD COLUMN coupon_code VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN discount    BIGINT      NOT NULL DEFAULT 0;
+
+CREATE INDEX orders_coupon_id_idx ON orders (coupon_id) WHERE coupon_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX orders_coupon_id_idx;
+ALTER TABLE orders
+    DROP COLUMN discount,
+    DROP COLUMN coupon_code,
+    DROP COLUMN coupon_id;
+DROP TABLE coupon;
@@ -0,0 +1,53 @@
+-- +goose Up
+ALTER TABLE product
+    ADD COLUMN tax_class VARCHAR(64) NOT NULL DEFAULT 'standard';
+
+ALTER TABLE order_item
+    ADD COLUMN tax_class VARCHAR(64) NOT NULL DEFAULT 'standard';
+
+-- Rates are in basis points: 2000 is 20%. A rule with an empty region applies to the whole country
+-- unless there is a rule for the region of the order.
+CREATE TABLE tax_rule
+(
+    id         UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    country    VARCHAR(2)  NOT NULL,
+    region     VARCHAR(16) NOT NULL DEFAULT '',
+    tax_class  VARCHAR(64) NOT NULL,
+    name       VARCHAR(64) NOT NULL,
+    rate       INTEGER     NOT NULL CHECK (rate >= 0 AND rate <= 10000),
+    -- Prices of inclusive rules already contain the tax, exclusive taxes are added to the total.
+    inclusive  BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (country, region, tax_class)
+);
+
+ALTER TABLE orders
+    ADD COLUMN tax_country VARCHAR(2)  NOT NULL DEFAULT '',
+    ADD COLUMN tax_region  VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN tax         BIGINT      NOT NULL DEFAULT 0;
+
+-- Taxes charged for the order, one line per tax class. Rules are copied, so later changes do not affect the order.
+CREATE TABLE order_tax_line
+(
+    order_id       UUID        NOT NULL,
+    tax_class      VARCHAR(64) NOT NULL,
+    name           VARCHAR(64) NOT NULL,
+    rate           INTEGER     NOT NULL,
+    inclusive      BOOLEAN     NOT NULL,
+    taxable_amount BIGINT      NOT NULL,
+    amount         BIGINT      NOT NULL,
+    PRIMARY KEY (order_id, tax_class),
+    FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE order_tax_line;
+ALTER TABLE orders
+    DROP COLUMN tax,
+    DROP COLUMN tax_region,
+    DROP COLUMN tax_country;
+DROP TABLE tax_rule;
+ALTER TABLE order_item
+    DROP COLUMN tax_class;
+ALTER TABLE product
+    DROP COLUMN tax_class;
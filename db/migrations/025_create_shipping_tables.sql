@@ -0,0 +1,66 @@
+-- +goose Up
+ALTER TABLE product
+    ADD COLUMN weight INTEGER NOT NULL DEFAULT 0 CHECK (weight >= 0);
+
+ALTER TABLE order_item
+    ADD COLUMN unit_weight INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE shipping_method
+(
+    id          UUID PRIMARY KEY      DEFAULT uuid_generate_v4(),
+    name        VARCHAR(255) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    rate_type   TEXT         NOT NULL CHECK (rate_type IN ('flat', 'weight', 'order_value')),
+    flat_rate   BIGINT       NOT NULL DEFAULT 0 CHECK (flat_rate >= 0),
+    -- Shipping is free for orders with at least this subtotal. Zero means never.
+    free_over   BIGINT       NOT NULL DEFAULT 0 CHECK (free_over >= 0),
+    -- ISO 3166-1 alpha-2 codes. Empty means all countries.
+    countries   TEXT[]       NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    disabled_at TIMESTAMPTZ
+);
+
+-- Rate tables of weight and order_value methods. The rate with the greatest min_value not above
+-- the weight in grams or the subtotal of the order applies.
+CREATE TABLE shipping_rate
+(
+    method_id UUID   NOT NULL,
+    min_value BIGINT NOT NULL CHECK (min_value >= 0),
+    rate      BIGINT NOT NULL CHECK (rate >= 0),
+    PRIMARY KEY (method_id, min_value),
+    FOREIGN KEY (method_id) REFERENCES shipping_method (id) ON DELETE CASCADE
+);
+
+ALTER TABLE orders
+    ADD COLUMN shipping_method_id   UUID REFERENCES shipping_method (id),
+    ADD COLUMN shipping_method_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN shipping_cost        BIGINT       NOT NULL DEFAULT 0;
+
+CREATE TABLE order_address
+(
+    order_id    UUID         NOT NULL,
+    type        TEXT         NOT NULL CHECK (type IN ('shipping', 'billing')),
+    full_name   VARCHAR(255) NOT NULL,
+    line1       VARCHAR(255) NOT NULL,
+    line2       VARCHAR(255) NOT NULL DEFAULT '',
+    city        VARCHAR(255) NOT NULL,
+    region      VARCHAR(16)  NOT NULL DEFAULT '',
+    postal_code VARCHAR(32)  NOT NULL DEFAULT '',
+    country     VARCHAR(2)   NOT NULL,
+    phone       VARCHAR(32)  NOT NULL DEFAULT '',
+    PRIMARY KEY (order_id, type),
+    FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE order_address;
+ALTER TABLE orders
+    DROP COLUMN shipping_cost,
+    DROP COLUMN shipping_method_name,
+    DROP COLUMN shipping_method_id;
+DROP TABLE shipping_rate;
+DROP TABLE shipping_method;
+ALTER TABLE order_item
+    DROP COLUMN unit_weight;
+ALTER TABLE product
+    DROP COLUMN weight;
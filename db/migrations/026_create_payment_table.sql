@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE payment
+(
+    id              UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    order_id        UUID        NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    provider        TEXT        NOT NULL,
+    -- ID of the payment at the provider, empty until the provider has accepted it.
+    reference       TEXT        NOT NULL DEFAULT '',
+    status          TEXT        NOT NULL CHECK (status IN ('pending', 'authorized', 'captured', 'voided', 'refunded', 'failed')),
+    amount          BIGINT      NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT      NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    refunded_amount BIGINT      NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0 AND refunded_amount <= captured_amount),
+    failure_reason  TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX payment_order_id_idx ON payment (order_id, created_at);
+
+-- An order can be paid again only after its previous payments failed or were voided.
+CREATE UNIQUE INDEX payment_active_order_id_idx ON payment (order_id) WHERE status NOT IN ('failed', 'voided');
+
+CREATE UNIQUE INDEX payment_reference_idx ON payment (provider, reference) WHERE reference <> '';
+
+-- +goose Down
+DROP TABLE payment;
@@ -7,15 +7,23 @@ import (
 	"go_store/config"
 	"go_store/db"
 	"go_store/generated/proto/admin"
+	"go_store/generated/proto/cart"
+	"go_store/generated/proto/customer"
 	"go_store/generated/proto/order"
 	"go_store/generated/proto/product"
 	controller "go_store/internal/controller/grpc"
+	httpcontroller "go_store/internal/controller/http"
 	"go_store/internal/controller/interceptor"
+	"go_store/internal/jwtkeys"
+	"go_store/internal/mailer"
+	"go_store/internal/model"
+	"go_store/internal/payment"
 	"go_store/internal/repository"
 	"go_store/internal/usecase"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -24,6 +32,12 @@ import (
 
 const sleepDuration = 3
 
+// idempotencyKeyCleanupInterval is how often expired idempotency keys of orders are removed.
+const idempotencyKeyCleanupInterval = time.Hour
+
+// pendingPaymentCheckInterval is how often payments pending for too long are marked failed.
+const pendingPaymentCheckInterval = time.Minute
+
 func Run(logger *zap.Logger, cfg *config.Config) {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
@@ -39,21 +53,104 @@ func Run(logger *zap.Logger, cfg *config.Config) {
 
 	db.SetupPostgres(dbPool, logger)
 
+	keys, err := jwtkeys.Load(&cfg.Admin)
+	if err != nil {
+		logger.Error("can not load jwt keys", zap.Error(err))
+		return
+	}
+
+	mail, err := mailer.New(&cfg.Mail, logger)
+	if err != nil {
+		logger.Error("can not create mailer", zap.Error(err))
+		return
+	}
+
+	paymentProvider, err := payment.New(&cfg.Payment)
+	if err != nil {
+		logger.Error("can not create payment provider", zap.Error(err))
+		return
+	}
+
 	productRepository := repository.NewProductRepository(dbPool)
+	categoryRepository := repository.NewCategoryRepository(dbPool)
 	orderRepository := repository.NewOrderRepository(dbPool)
+	adminRepository := repository.NewAdminRepository(dbPool)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(dbPool)
+	revocationRepository := repository.NewRevocationRepository(dbPool)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(dbPool)
+	loginChallengeRepository := repository.NewLoginChallengeRepository(dbPool)
+	customerRepository := repository.NewCustomerRepository(dbPool)
+	cartRepository := repository.NewCartRepository(dbPool)
+	couponRepository := repository.NewCouponRepository(dbPool)
+	taxRepository := repository.NewTaxRepository(dbPool)
+	shippingRepository := repository.NewShippingRepository(dbPool)
+	paymentRepository := repository.NewPaymentRepository(dbPool)
+
+	denylist := usecase.NewTokenDenylist(logger, revocationRepository, cfg.Admin.AccessTokenTTL)
+	if err = denylist.Refresh(ctx); err != nil {
+		logger.Error("can not load token denylist", zap.Error(err))
+		return
+	}
+	go denylist.Run(ctx, cfg.Admin.DenylistRefresh)
 
 	productUseCase := usecase.NewProductUseCase(logger, productRepository)
-	orderUseCase := usecase.NewOrderUseCase(logger, orderRepository)
-	adminUseCase := usecase.NewAdminUseCase(logger, &cfg.Admin)
+	categoryUseCase := usecase.NewCategoryUseCase(logger, categoryRepository)
+	orderUseCase := usecase.NewOrderUseCase(logger, orderRepository, taxRepository, shippingRepository)
+	go orderUseCase.Run(ctx, idempotencyKeyCleanupInterval)
+	adminThrottle := newLoginThrottle(logger, loginAttemptRepository, "admin", &cfg.Admin.Login)
+	adminUseCase := usecase.NewAdminUseCase(
+		logger, &cfg.Admin, keys,
+		adminRepository, refreshTokenRepository, denylist, adminThrottle, loginChallengeRepository,
+	)
+
+	customerThrottle := newLoginThrottle(logger, loginAttemptRepository, "customer", &cfg.Customer.Login)
+	customerEmailThrottle := newLoginThrottle(logger, loginAttemptRepository, "customer-email", &cfg.Customer.Email)
+	customerUseCase := usecase.NewCustomerUseCase(logger, &cfg.Customer, keys, customerRepository, cartRepository,
+		customerThrottle, customerEmailThrottle, mail)
+	cartUseCase := usecase.NewCartUseCase(logger, cartRepository, orderUseCase)
+	couponUseCase := usecase.NewCouponUseCase(logger, couponRepository)
+	taxUseCase := usecase.NewTaxUseCase(logger, taxRepository)
+	shippingUseCase := usecase.NewShippingUseCase(logger, shippingRepository, productRepository)
+	paymentUseCase := usecase.NewPaymentUseCase(logger, paymentRepository, orderUseCase, paymentProvider)
+	go paymentUseCase.Run(ctx, pendingPaymentCheckInterval)
+
+	if err = adminUseCase.EnsureSuperuser(ctx); err != nil {
+		logger.Error("can not create initial superuser", zap.Error(err))
+		return
+	}
 
-	ctrl := controller.New(logger, productUseCase, orderUseCase, adminUseCase)
-	go runGrpc(cfg, logger, ctrl)
+	ctrl := controller.New(logger, productUseCase, categoryUseCase, orderUseCase, adminUseCase, customerUseCase, cartUseCase,
+		couponUseCase, taxUseCase, shippingUseCase, paymentUseCase)
+	go runGrpc(cfg, logger, ctrl,
+		interceptor.ErrorInterceptor(logger),
+		interceptor.AuthInterceptor(&cfg.Admin, keys, denylist),
+		interceptor.CustomerAuthInterceptor(&cfg.Customer, keys, customerUseCase),
+	)
+	if cfg.HTTP.Port != "" {
+		go runHTTP(cfg, logger, keys, paymentUseCase)
+	}
 
 	<-ctx.Done()
 	time.Sleep(time.Second * sleepDuration)
 }
 
-func runGrpc(cfg *config.Config, logger *zap.Logger, server controller.Server) {
+// newLoginThrottle creates a login throttle for the scope with the configured lockout policy.
+func newLoginThrottle(logger *zap.Logger, repo repository.LoginAttemptRepository, scope string, cfg *config.Login) usecase.LoginThrottle {
+	return usecase.NewLoginThrottle(logger, repo, scope,
+		model.LockoutPolicy{
+			MaxFailures: cfg.MaxFailures,
+			Lockout:     cfg.Lockout,
+			MaxLockout:  cfg.MaxLockout,
+		},
+		model.LockoutPolicy{
+			MaxFailures: cfg.MaxFailuresPerIP,
+			Lockout:     cfg.Lockout,
+			MaxLockout:  cfg.MaxLockout,
+		},
+	)
+}
+
+func runGrpc(cfg *config.Config, logger *zap.Logger, server controller.Server, interceptors ...grpc.UnaryServerInterceptor) {
 	port := ":" + cfg.GRPC.Port
 	lis, err := net.Listen("tcp", port)
 
@@ -62,12 +159,14 @@ func runGrpc(cfg *config.Config, logger *zap.Logger, server controller.Server) {
 		os.Exit(-1)
 	}
 
-	s := grpc.NewServer(grpc.UnaryInterceptor(interceptor.AuthInterceptor(cfg.Admin.JWTSecret)))
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
 	reflection.Register(s)
 
 	product.RegisterProductServiceServer(s, server)
 	order.RegisterOrderServiceServer(s, server)
 	admin.RegisterAdminServiceServer(s, server)
+	customer.RegisterCustomerServiceServer(s, server)
+	cart.RegisterCartServiceServer(s, server)
 
 	logger.Info("grpc server listening at port", zap.String("port", port))
 
@@ -75,3 +174,18 @@ func runGrpc(cfg *config.Config, logger *zap.Logger, server controller.Server) {
 		logger.Error("grpc server listen error", zap.Error(err))
 	}
 }
+
+func runHTTP(cfg *config.Config, logger *zap.Logger, keys *jwtkeys.KeySet, payments usecase.PaymentUseCase) {
+	port := ":" + cfg.HTTP.Port
+
+	mux := http.NewServeMux()
+	mux.Handle(httpcontroller.JWKSPath, httpcontroller.JWKSHandler(logger, keys))
+	mux.Handle(httpcontroller.PaymentWebhookPath, httpcontroller.PaymentWebhookHandler(logger, payments))
+
+	logger.Info("http server listening at port", zap.String("port", port))
+
+	server := &http.Server{Addr: port, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		logger.Error("http server listen error", zap.Error(err))
+	}
+}
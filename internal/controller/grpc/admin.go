@@ -0,0 +1,153 @@
+package grpc
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/generated/proto/admin"
+	"go_store/internal/controller/interceptor"
+	"go_store/internal/model"
+)
+
+func (i *Implementation) CreateAdmin(ctx context.Context, request *admin.CreateAdminRequest) (*admin.CreateAdminResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	roles := make([]model.AdminRole, 0, len(request.Roles))
+	for _, role := range request.Roles {
+		roles = append(roles, model.AdminRoleFromMessage(role))
+	}
+	result, err := i.adminUseCase.Create(ctx, request.Username, request.Password, roles)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.CreateAdminResponse{Id: result}, nil
+}
+
+func (i *Implementation) DisableAdmin(ctx context.Context, request *admin.DisableAdminRequest) (*admin.DisableAdminResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	username, _ := interceptor.AdminFromContext(ctx)
+	if err := i.adminUseCase.Disable(ctx, request.Id, username); err != nil {
+		return nil, err
+	}
+	return &admin.DisableAdminResponse{}, nil
+}
+
+func (i *Implementation) ListAdmins(ctx context.Context, request *admin.ListAdminsRequest) (*admin.ListAdminsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.adminUseCase.List(ctx, request.Limit, request.Offset)
+	if err != nil {
+		return nil, err
+	}
+	admins := make([]*admin.AdminUser, 0, len(result))
+	for _, a := range result {
+		admins = append(admins, a.ConvertToMessage())
+	}
+	return &admin.ListAdminsResponse{Admins: admins}, nil
+}
+
+func (i *Implementation) ChangePassword(ctx context.Context, request *admin.ChangePasswordRequest) (*admin.ChangePasswordResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	username, _ := interceptor.AdminFromContext(ctx)
+	if err := i.adminUseCase.ChangePassword(ctx, username, request.CurrentPassword, request.NewPassword); err != nil {
+		return nil, err
+	}
+	return &admin.ChangePasswordResponse{}, nil
+}
+
+func (i *Implementation) Logout(ctx context.Context, request *admin.LogoutRequest) (*admin.LogoutResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	claims, _ := interceptor.AdminClaimsFromContext(ctx)
+	if err := i.adminUseCase.Logout(ctx, claims, request.RefreshToken); err != nil {
+		return nil, err
+	}
+	return &admin.LogoutResponse{}, nil
+}
+
+func (i *Implementation) RevokeAdminSessions(ctx context.Context, request *admin.RevokeAdminSessionsRequest) (*admin.RevokeAdminSessionsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.adminUseCase.RevokeSessions(ctx, request.Id); err != nil {
+		return nil, err
+	}
+	return &admin.RevokeAdminSessionsResponse{}, nil
+}
+
+func (i *Implementation) UnlockLogin(ctx context.Context, request *admin.UnlockLoginRequest) (*admin.UnlockLoginResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if request.Username == "" && request.ClientIp == "" {
+		err := &model.InvalidArgumentError{Field: "username", Description: "username or client_ip must be set"}
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.adminUseCase.UnlockLogin(ctx, request.Username, request.ClientIp); err != nil {
+		return nil, err
+	}
+	return &admin.UnlockLoginResponse{}, nil
+}
+
+func (i *Implementation) EnrollTotp(ctx context.Context, request *admin.EnrollTotpRequest) (*admin.EnrollTotpResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	username, _ := interceptor.AdminFromContext(ctx)
+	enrollment, err := i.adminUseCase.EnrollTOTP(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.EnrollTotpResponse{Secret: enrollment.Secret, ProvisioningUri: enrollment.ProvisioningURI}, nil
+}
+
+func (i *Implementation) ConfirmTotp(ctx context.Context, request *admin.ConfirmTotpRequest) (*admin.ConfirmTotpResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	username, _ := interceptor.AdminFromContext(ctx)
+	codes, err := i.adminUseCase.ConfirmTOTP(ctx, username, request.Code, clientIP(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &admin.ConfirmTotpResponse{RecoveryCodes: codes}, nil
+}
+
+func (i *Implementation) DisableTotp(ctx context.Context, request *admin.DisableTotpRequest) (*admin.DisableTotpResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	username, _ := interceptor.AdminFromContext(ctx)
+	if err := i.adminUseCase.DisableTOTP(ctx, username, request.GetCode(), request.GetRecoveryCode(), clientIP(ctx)); err != nil {
+		return nil, err
+	}
+	return &admin.DisableTotpResponse{}, nil
+}
+
+func (i *Implementation) ResetAdminTotp(ctx context.Context, request *admin.ResetAdminTotpRequest) (*admin.ResetAdminTotpResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.adminUseCase.ResetTOTP(ctx, request.Id); err != nil {
+		return nil, err
+	}
+	return &admin.ResetAdminTotpResponse{}, nil
+}
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/generated/proto/cart"
+	"go_store/internal/controller/interceptor"
+	"go_store/internal/model"
+)
+
+func (i *Implementation) CreateCart(ctx context.Context, request *cart.CreateCartRequest) (*cart.CreateCartResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.cartUseCase.Create(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return &cart.CreateCartResponse{Cart: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) GetCart(ctx context.Context, request *cart.GetCartRequest) (*cart.GetCartResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.cartUseCase.Get(ctx, request.CartId, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return &cart.GetCartResponse{Cart: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) AddCartItem(ctx context.Context, request *cart.AddCartItemRequest) (*cart.AddCartItemResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.cartUseCase.AddItem(ctx, request.CartId, customerID, request.ProductId, request.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return &cart.AddCartItemResponse{Cart: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) UpdateCartItem(ctx context.Context, request *cart.UpdateCartItemRequest) (*cart.UpdateCartItemResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.cartUseCase.UpdateItem(ctx, request.CartId, customerID, request.ProductId, request.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return &cart.UpdateCartItemResponse{Cart: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) RemoveCartItem(ctx context.Context, request *cart.RemoveCartItemRequest) (*cart.RemoveCartItemResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.cartUseCase.RemoveItem(ctx, request.CartId, customerID, request.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	return &cart.RemoveCartItemResponse{Cart: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) Checkout(ctx context.Context, request *cart.CheckoutRequest) (*cart.CheckoutResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.cartUseCase.Checkout(ctx, request.CartId, &model.Order{
+		CustomerID:       customerID,
+		CustomerName:     request.CustomerName,
+		CustomerEmail:    request.CustomerEmail,
+		CouponCode:       request.CouponCode,
+		ShippingAddress:  model.AddressFromMessage(request.ShippingAddress),
+		BillingAddress:   model.AddressFromMessage(request.BillingAddress),
+		ShippingMethodID: request.ShippingMethodId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cart.CheckoutResponse{OrderId: result.ID, AccessToken: result.AccessToken}, nil
+}
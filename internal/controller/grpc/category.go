@@ -0,0 +1,111 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"go_store/generated/proto/admin"
+	"go_store/generated/proto/common"
+	"go_store/generated/proto/product"
+	"go_store/internal/model"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func (i *Implementation) ListCategories(ctx context.Context, request *product.ListCategoriesRequest) (*product.ListCategoriesResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.categoryUseCase.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories := make([]*common.Category, 0, len(result))
+	for _, c := range result {
+		categories = append(categories, c.ConvertToMessage())
+	}
+	return &product.ListCategoriesResponse{Categories: categories}, nil
+}
+
+func (i *Implementation) CreateCategory(ctx context.Context, request *admin.CreateCategoryRequest) (*admin.CreateCategoryResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.categoryUseCase.Create(ctx, &model.Category{
+		ParentID:    request.ParentId,
+		Name:        request.Name,
+		Description: request.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &admin.CreateCategoryResponse{Id: result}, nil
+}
+
+func (i *Implementation) UpdateCategory(ctx context.Context, request *admin.UpdateCategoryRequest) (*admin.UpdateCategoryResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	fields, err := categoryUpdateFields(request.UpdateMask)
+	if err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.categoryUseCase.Update(ctx, &model.Category{
+		ID:          request.Id,
+		ParentID:    request.ParentId,
+		Name:        request.Name,
+		Description: request.Description,
+	}, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.UpdateCategoryResponse{Category: result.ConvertToMessage()}, nil
+}
+
+// categoryUpdatePaths maps UpdateCategoryRequest field mask paths to category fields.
+var categoryUpdatePaths = map[string]string{
+	"name":        model.CategoryFieldName,
+	"description": model.CategoryFieldDescription,
+	"parent_id":   model.CategoryFieldParentID,
+}
+
+func categoryUpdateFields(mask *fieldmaskpb.FieldMask) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, &model.InvalidArgumentError{Field: "update_mask", Description: "must contain at least one path"}
+	}
+	mask.Normalize()
+	fields := make([]string, 0, len(mask.GetPaths()))
+	for _, path := range mask.GetPaths() {
+		field, ok := categoryUpdatePaths[path]
+		if !ok {
+			return nil, &model.InvalidArgumentError{Field: "update_mask", Description: fmt.Sprintf("path %q is not supported", path)}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func (i *Implementation) DeleteCategory(ctx context.Context, request *admin.DeleteCategoryRequest) (*admin.DeleteCategoryResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.categoryUseCase.Delete(ctx, request.Id); err != nil {
+		return nil, err
+	}
+	return &admin.DeleteCategoryResponse{}, nil
+}
+
+func (i *Implementation) SetProductCategories(ctx context.Context, request *admin.SetProductCategoriesRequest) (*admin.SetProductCategoriesResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.categoryUseCase.SetProductCategories(ctx, request.ProductId, request.CategoryIds); err != nil {
+		return nil, err
+	}
+	return &admin.SetProductCategoriesResponse{}, nil
+}
@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"context"
+	"go_store/internal/model"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+)
+
+// clientIP returns the address of the connected client, or an empty string if it is unknown.
+// Forwarding headers are not trusted, since any client can set them.
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// idempotencyKey returns the key from the request field or the "idempotency-key" metadata.
+// Both may be set only if they are equal.
+func idempotencyKey(ctx context.Context, field string) (string, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "idempotency-key")
+	if len(values) > 1 {
+		return "", &model.InvalidArgumentError{Field: "idempotency_key", Description: "metadata must contain one key"}
+	}
+	if len(values) == 0 || values[0] == field {
+		return field, nil
+	}
+	if field != "" {
+		return "", &model.InvalidArgumentError{Field: "idempotency_key", Description: "does not match the idempotency-key metadata"}
+	}
+	if len(values[0]) > 255 {
+		return "", &model.InvalidArgumentError{Field: "idempotency_key", Description: "must be at most 255 characters"}
+	}
+	return values[0], nil
+}
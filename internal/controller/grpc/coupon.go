@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/generated/proto/admin"
+	"go_store/internal/model"
+)
+
+func (i *Implementation) CreateCoupon(ctx context.Context, request *admin.CreateCouponRequest) (*admin.CreateCouponResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	id, err := i.couponUseCase.Create(ctx, &model.Coupon{
+		Code:               request.Code,
+		Type:               model.CouponTypeFromMessage(request.Type),
+		Value:              request.Value,
+		FreeProductID:      request.FreeProductId,
+		MinOrderValue:      request.MinOrderValue,
+		StartsAt:           timeOrNil(request.StartsAt),
+		EndsAt:             timeOrNil(request.EndsAt),
+		MaxUses:            request.MaxUses,
+		MaxUsesPerCustomer: request.MaxUsesPerCustomer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &admin.CreateCouponResponse{Id: id}, nil
+}
+
+func (i *Implementation) ListCoupons(ctx context.Context, request *admin.ListCouponsRequest) (*admin.ListCouponsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.couponUseCase.List(ctx, request.Limit, request.Offset)
+	if err != nil {
+		return nil, err
+	}
+	coupons := make([]*admin.Coupon, 0, len(result))
+	for _, c := range result {
+		coupons = append(coupons, c.ConvertToMessage())
+	}
+	return &admin.ListCouponsResponse{Coupons: coupons}, nil
+}
+
+func (i *Implementation) DisableCoupon(ctx context.Context, request *admin.DisableCouponRequest) (*admin.DisableCouponResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.couponUseCase.Disable(ctx, request.Id); err != nil {
+		return nil, err
+	}
+	return &admin.DisableCouponResponse{}, nil
+}
@@ -0,0 +1,169 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"go_store/generated/proto/common"
+	"go_store/generated/proto/customer"
+	"go_store/internal/controller/interceptor"
+	"go_store/internal/model"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (i *Implementation) RegisterCustomer(ctx context.Context, request *customer.RegisterCustomerRequest) (*customer.RegisterCustomerResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	id, err := i.customerUseCase.Register(ctx, request.Email, request.Password, request.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &customer.RegisterCustomerResponse{Id: id}, nil
+}
+
+func (i *Implementation) CustomerLogin(ctx context.Context, request *customer.CustomerLoginRequest) (*customer.CustomerLoginResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	token, err := i.customerUseCase.Login(ctx, request.Email, request.Password, clientIP(ctx), request.GuestCartId)
+	if err != nil {
+		return nil, err
+	}
+	return &customer.CustomerLoginResponse{
+		Token:     token.AccessToken,
+		ExpiresAt: timestamppb.New(token.ExpiresAt),
+	}, nil
+}
+
+func (i *Implementation) GetProfile(ctx context.Context, request *customer.GetProfileRequest) (*customer.GetProfileResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.customerUseCase.Get(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return &customer.GetProfileResponse{Customer: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) UpdateProfile(ctx context.Context, request *customer.UpdateProfileRequest) (*customer.UpdateProfileResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	fields, err := profileUpdateFields(request.UpdateMask)
+	if err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.customerUseCase.Update(ctx, &model.Customer{
+		ID:    customerID,
+		Email: request.Email,
+		Name:  request.Name,
+	}, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &customer.UpdateProfileResponse{Customer: result.ConvertToMessage()}, nil
+}
+
+// profileUpdatePaths maps UpdateProfileRequest field mask paths to customer fields.
+var profileUpdatePaths = map[string]string{
+	"email": model.CustomerFieldEmail,
+	"name":  model.CustomerFieldName,
+}
+
+func profileUpdateFields(mask *fieldmaskpb.FieldMask) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, &model.InvalidArgumentError{Field: "update_mask", Description: "must contain at least one path"}
+	}
+	mask.Normalize()
+	fields := make([]string, 0, len(mask.GetPaths()))
+	for _, path := range mask.GetPaths() {
+		field, ok := profileUpdatePaths[path]
+		if !ok {
+			return nil, &model.InvalidArgumentError{Field: "update_mask", Description: fmt.Sprintf("path %q is not supported", path)}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func (i *Implementation) ListMyOrders(ctx context.Context, request *customer.ListMyOrdersRequest) (*customer.ListMyOrdersResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	after, err := pageCursor[model.OrderCursor](request.PageToken, 0)
+	if err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	filter := model.OrderFilter{CustomerID: customerID}
+	result, next, err := i.orderUseCase.List(ctx, filter, model.OrderSort{}, after, request.Limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	nextToken, err := nextPageToken(next)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]*common.Order, 0, len(result))
+	for _, o := range result {
+		orders = append(orders, customerOrderMessage(&o))
+	}
+	return &customer.ListMyOrdersResponse{Orders: orders, NextPageToken: nextToken}, nil
+}
+
+func (i *Implementation) RequestEmailVerification(ctx context.Context, request *customer.RequestEmailVerificationRequest) (*customer.RequestEmailVerificationResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	if err := i.customerUseCase.RequestEmailVerification(ctx, customerID, clientIP(ctx)); err != nil {
+		return nil, err
+	}
+	return &customer.RequestEmailVerificationResponse{}, nil
+}
+
+func (i *Implementation) VerifyEmail(ctx context.Context, request *customer.VerifyEmailRequest) (*customer.VerifyEmailResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.customerUseCase.VerifyEmail(ctx, request.Token); err != nil {
+		return nil, err
+	}
+	return &customer.VerifyEmailResponse{}, nil
+}
+
+func (i *Implementation) RequestPasswordReset(ctx context.Context, request *customer.RequestPasswordResetRequest) (*customer.RequestPasswordResetResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.customerUseCase.RequestPasswordReset(ctx, request.Email, clientIP(ctx)); err != nil {
+		return nil, err
+	}
+	return &customer.RequestPasswordResetResponse{}, nil
+}
+
+func (i *Implementation) ResetPassword(ctx context.Context, request *customer.ResetPasswordRequest) (*customer.ResetPasswordResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.customerUseCase.ResetPassword(ctx, request.Token, request.NewPassword); err != nil {
+		return nil, err
+	}
+	return &customer.ResetPasswordResponse{}, nil
+}
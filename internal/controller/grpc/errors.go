@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"errors"
+	"go_store/internal/model"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validationError is implemented by the errors generated by protoc-gen-validate.
+type validationError interface {
+	error
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// multiError is implemented by the errors returned from generated ValidateAll methods.
+type multiError interface {
+	error
+	AllErrors() []error
+}
+
+// invalidArgument converts a request validation error into an InvalidArgument status
+// with a BadRequest detail listing every violated field.
+func invalidArgument(err error) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	var all multiError
+	if errors.As(err, &all) {
+		for _, e := range all.AllErrors() {
+			violations = append(violations, fieldViolation(e))
+		}
+	} else {
+		violations = append(violations, fieldViolation(err))
+	}
+
+	detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// fieldViolation describes a single validation error. Errors of nested messages are
+// unwrapped, so the field path points to the innermost field, e.g. "items[0].quantity".
+func fieldViolation(err error) *errdetails.BadRequest_FieldViolation {
+	var argErr *model.InvalidArgumentError
+	if errors.As(err, &argErr) {
+		return &errdetails.BadRequest_FieldViolation{Field: argErr.Field, Description: argErr.Description}
+	}
+
+	var field string
+	for {
+		var vErr validationError
+		if !errors.As(err, &vErr) {
+			break
+		}
+		if field != "" {
+			field += "."
+		}
+		field += vErr.Field()
+		cause := vErr.Cause()
+		if cause == nil {
+			return &errdetails.BadRequest_FieldViolation{Field: field, Description: vErr.Reason()}
+		}
+		err = cause
+	}
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: err.Error()}
+}
@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"go_store/internal/model"
+)
+
+// Page tokens are opaque for clients: a cursor serialized to JSON and encoded with base64.
+
+var errInvalidPageToken = &model.InvalidArgumentError{Field: "page_token", Description: "invalid page token"}
+
+// pageCursor decodes the page token of a list request. It returns nil for the first page.
+// Offset is kept for backward compatibility and can not be combined with a page token.
+func pageCursor[T any](token string, offset int32) (*T, error) {
+	if token == "" {
+		return nil, nil
+	}
+	if offset != 0 {
+		return nil, &model.InvalidArgumentError{Field: "offset", Description: "can not be used together with page_token"}
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+	var cursor T
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return nil, errInvalidPageToken
+	}
+	return &cursor, nil
+}
+
+// nextPageToken encodes the cursor of the next page. It returns an empty token on the last page.
+func nextPageToken[T any](cursor *T) (string, error) {
+	if cursor == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,93 @@
+package grpc
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/generated/proto/admin"
+	"go_store/generated/proto/common"
+	"go_store/generated/proto/order"
+	"go_store/internal/controller/interceptor"
+	"go_store/internal/model"
+)
+
+func (i *Implementation) PayOrder(ctx context.Context, request *order.PayOrderRequest) (*order.PayOrderResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.paymentUseCase.Authorize(ctx, request.OrderId, customerID, request.AccessToken, request.PaymentToken)
+	if err != nil {
+		return nil, err
+	}
+	return &order.PayOrderResponse{Payment: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) ListOrderPayments(ctx context.Context, request *order.ListOrderPaymentsRequest) (*order.ListOrderPaymentsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.paymentUseCase.ListForCustomer(ctx, request.OrderId, customerID, request.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &order.ListOrderPaymentsResponse{Payments: paymentsToMessage(result)}, nil
+}
+
+func (i *Implementation) ListPayments(ctx context.Context, request *admin.ListPaymentsRequest) (*admin.ListPaymentsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.paymentUseCase.List(ctx, request.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.ListPaymentsResponse{Payments: paymentsToMessage(result)}, nil
+}
+
+func (i *Implementation) CapturePayment(ctx context.Context, request *admin.CapturePaymentRequest) (*admin.CapturePaymentResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.paymentUseCase.Capture(ctx, request.Id, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.CapturePaymentResponse{Payment: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) VoidPayment(ctx context.Context, request *admin.VoidPaymentRequest) (*admin.VoidPaymentResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.paymentUseCase.Void(ctx, request.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.VoidPaymentResponse{Payment: result.ConvertToMessage()}, nil
+}
+
+func (i *Implementation) RefundPayment(ctx context.Context, request *admin.RefundPaymentRequest) (*admin.RefundPaymentResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.paymentUseCase.Refund(ctx, request.Id, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.RefundPaymentResponse{Payment: result.ConvertToMessage()}, nil
+}
+
+func paymentsToMessage(payments []model.Payment) []*common.Payment {
+	result := make([]*common.Payment, 0, len(payments))
+	for _, p := range payments {
+		result = append(result, p.ConvertToMessage())
+	}
+	return result
+}
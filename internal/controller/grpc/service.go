@@ -2,15 +2,20 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"go.uber.org/zap"
 	"go_store/generated/proto/admin"
+	"go_store/generated/proto/cart"
 	"go_store/generated/proto/common"
+	"go_store/generated/proto/customer"
 	"go_store/generated/proto/order"
 	"go_store/generated/proto/product"
+	"go_store/internal/controller/interceptor"
 	"go_store/internal/model"
 	"go_store/internal/usecase"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
 )
 
 var _ Server = (*Implementation)(nil)
@@ -19,93 +24,278 @@ type Server interface {
 	product.ProductServiceServer
 	order.OrderServiceServer
 	admin.AdminServiceServer
+	customer.CustomerServiceServer
+	cart.CartServiceServer
 }
 
 type Implementation struct {
-	logger         *zap.Logger
-	productUseCase usecase.ProductUseCase
-	orderUseCase   usecase.OrderUseCase
-	adminUseCase   usecase.AdminUseCase
+	logger          *zap.Logger
+	productUseCase  usecase.ProductUseCase
+	categoryUseCase usecase.CategoryUseCase
+	orderUseCase    usecase.OrderUseCase
+	adminUseCase    usecase.AdminUseCase
+	customerUseCase usecase.CustomerUseCase
+	cartUseCase     usecase.CartUseCase
+	couponUseCase   usecase.CouponUseCase
+	taxUseCase      usecase.TaxUseCase
+	shippingUseCase usecase.ShippingUseCase
+	paymentUseCase  usecase.PaymentUseCase
 }
 
 func (i *Implementation) Login(ctx context.Context, request *admin.AdminLoginRequest) (*admin.AdminLoginResponse, error) {
 	if err := request.ValidateAll(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
 	}
-	token, err := i.adminUseCase.Login(ctx, request.Username, request.Password)
+	tokens, err := i.adminUseCase.Login(ctx, request.Username, request.Password, clientIP(ctx))
 	if err != nil {
-		return nil, status.Error(codes.Unauthenticated, err.Error())
+		return nil, err
 	}
+	return loginResponse(tokens), nil
+}
+
+func (i *Implementation) CompleteLogin(ctx context.Context, request *admin.CompleteLoginRequest) (*admin.AdminLoginResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	tokens, err := i.adminUseCase.CompleteLogin(ctx, request.MfaChallenge, request.GetCode(), request.GetRecoveryCode(), clientIP(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return loginResponse(tokens), nil
+}
 
-	return &admin.AdminLoginResponse{Token: token}, nil
+func loginResponse(tokens *model.AdminTokens) *admin.AdminLoginResponse {
+	if tokens.Challenge != "" {
+		return &admin.AdminLoginResponse{
+			MfaRequired:           true,
+			MfaChallenge:          tokens.Challenge,
+			MfaChallengeExpiresAt: timestamppb.New(tokens.ChallengeExpiresAt),
+		}
+	}
+	return &admin.AdminLoginResponse{
+		Token:                 tokens.AccessToken,
+		ExpiresAt:             timestamppb.New(tokens.AccessTokenExpiresAt),
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(tokens.RefreshTokenExpiresAt),
+	}
+}
+
+func (i *Implementation) RefreshToken(ctx context.Context, request *admin.RefreshTokenRequest) (*admin.RefreshTokenResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	tokens, err := i.adminUseCase.Refresh(ctx, request.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &admin.RefreshTokenResponse{
+		Token:                 tokens.AccessToken,
+		ExpiresAt:             timestamppb.New(tokens.AccessTokenExpiresAt),
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(tokens.RefreshTokenExpiresAt),
+	}, nil
 }
 
 func (i *Implementation) GetProduct(ctx context.Context, request *product.GetProductRequest) (*product.GetProductResponse, error) {
 	if err := request.Validate(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
 	}
 	result, err := i.productUseCase.Get(ctx, request.Id)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "product not found")
+		return nil, err
 	}
-	return &product.GetProductResponse{Product: &common.Product{
-		Id:          result.ID,
-		Name:        result.Name,
-		Description: result.Description,
-		Price:       result.Price,
-	}}, nil
+	return &product.GetProductResponse{Product: result.ConvertToMessage()}, nil
 }
 
 func (i *Implementation) ListProducts(ctx context.Context, request *product.ListProductsRequest) (*product.ListProductsResponse, error) {
 	if err := request.ValidateAll(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
+	}
+	after, err := pageCursor[model.ProductCursor](request.PageToken, request.Offset)
+	if err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	filter := model.ProductFilter{
+		CategoryID:         request.CategoryId,
+		IncludeDescendants: request.IncludeDescendants,
+	}
+	result, next, err := i.productUseCase.List(ctx, filter, after, request.Limit, request.Offset)
+	if err != nil {
+		return nil, err
 	}
-	result, err := i.productUseCase.List(ctx, request.Limit, request.Offset)
+	nextToken, err := nextPageToken(next)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "%s", err)
+		return nil, err
 	}
 	products := make([]*common.Product, 0, len(result))
 	for _, p := range result {
-		products = append(products, &common.Product{
-			Id:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
+		products = append(products, p.ConvertToMessage())
+	}
+	return &product.ListProductsResponse{Products: products, NextPageToken: nextToken}, nil
+}
+
+func (i *Implementation) SearchProducts(ctx context.Context, request *product.SearchProductsRequest) (*product.SearchProductsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.productUseCase.Search(ctx, request.Query, request.Limit, request.Offset)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*product.ProductSearchResult, 0, len(result))
+	for _, r := range result {
+		results = append(results, &product.ProductSearchResult{
+			Product:              r.Product.ConvertToMessage(),
+			Rank:                 r.Rank,
+			NameHighlight:        r.NameHighlight,
+			DescriptionHighlight: r.DescriptionHighlight,
 		})
 	}
-	return &product.ListProductsResponse{Products: products}, nil
+	return &product.SearchProductsResponse{Results: results}, nil
 }
 
 func (i *Implementation) CreateProduct(ctx context.Context, request *admin.CreateProductRequest) (*admin.CreateProductResponse, error) {
 	if err := request.ValidateAll(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
 	}
-	result, err := i.productUseCase.Create(ctx, request.Name, request.Description, request.Price)
+	result, err := i.productUseCase.Create(ctx, &model.Product{
+		Name:        request.Name,
+		Description: request.Description,
+		Price:       request.Price,
+		Stock:       request.Stock,
+		TaxClass:    request.TaxClass,
+		Weight:      request.Weight,
+	})
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 	return &admin.CreateProductResponse{Id: result}, nil
 }
 
+func (i *Implementation) UpdateProduct(ctx context.Context, request *admin.UpdateProductRequest) (*admin.UpdateProductResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	fields, err := productUpdateFields(request.UpdateMask)
+	if err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.productUseCase.Update(ctx, &model.Product{
+		ID:          request.Id,
+		Name:        request.Name,
+		Description: request.Description,
+		Price:       request.Price,
+		TaxClass:    request.TaxClass,
+		Weight:      request.Weight,
+	}, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.UpdateProductResponse{Product: result.ConvertToMessage()}, nil
+}
+
+// productUpdatePaths maps UpdateProductRequest field mask paths to product fields.
+var productUpdatePaths = map[string]string{
+	"name":        model.ProductFieldName,
+	"description": model.ProductFieldDescription,
+	"price":       model.ProductFieldPrice,
+	"tax_class":   model.ProductFieldTaxClass,
+	"weight":      model.ProductFieldWeight,
+}
+
+func productUpdateFields(mask *fieldmaskpb.FieldMask) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, &model.InvalidArgumentError{Field: "update_mask", Description: "must contain at least one path"}
+	}
+	mask.Normalize()
+	fields := make([]string, 0, len(mask.GetPaths()))
+	for _, path := range mask.GetPaths() {
+		field, ok := productUpdatePaths[path]
+		if !ok {
+			return nil, &model.InvalidArgumentError{Field: "update_mask", Description: fmt.Sprintf("path %q is not supported", path)}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
 func (i *Implementation) DeleteProduct(ctx context.Context, request *admin.DeleteProductRequest) (*admin.DeleteProductResponse, error) {
 	if err := request.ValidateAll(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
 	}
-	if err := i.productUseCase.Delete(ctx, request.Id); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+	if err := i.productUseCase.Archive(ctx, request.Id); err != nil {
+		return nil, err
 	}
 	return &admin.DeleteProductResponse{}, nil
 }
 
+func (i *Implementation) RestoreProduct(ctx context.Context, request *admin.RestoreProductRequest) (*admin.RestoreProductResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.productUseCase.Restore(ctx, request.Id); err != nil {
+		return nil, err
+	}
+	return &admin.RestoreProductResponse{}, nil
+}
+
+func (i *Implementation) ListArchivedProducts(ctx context.Context, request *admin.ListArchivedProductsRequest) (*admin.ListArchivedProductsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.productUseCase.ListArchived(ctx, request.Limit, request.Offset)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]*common.Product, 0, len(result))
+	for _, p := range result {
+		products = append(products, p.ConvertToMessage())
+	}
+	return &admin.ListArchivedProductsResponse{Products: products}, nil
+}
+
+func (i *Implementation) SetProductStock(ctx context.Context, request *admin.SetProductStockRequest) (*admin.SetProductStockResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	stock, err := i.productUseCase.SetStock(ctx, request.Id, request.Stock)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.SetProductStockResponse{Stock: stock}, nil
+}
+
+func (i *Implementation) AdjustProductStock(ctx context.Context, request *admin.AdjustProductStockRequest) (*admin.AdjustProductStockResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	stock, err := i.productUseCase.AdjustStock(ctx, request.Id, request.Delta)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.AdjustProductStockResponse{Stock: stock}, nil
+}
+
 func (i *Implementation) CreateOrder(ctx context.Context, request *order.CreateOrderRequest) (*order.CreateOrderResponse, error) {
 	if err := request.ValidateAll(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
 	}
 	items := make([]model.OrderItem, 0, len(request.Items))
 	for _, item := range request.Items {
@@ -114,63 +304,207 @@ func (i *Implementation) CreateOrder(ctx context.Context, request *order.CreateO
 			Quantity:  item.Quantity,
 		})
 	}
-	result, err := i.orderUseCase.Create(ctx, request.CustomerName, request.CustomerEmail, items)
+	key, err := idempotencyKey(ctx, request.IdempotencyKey)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.orderUseCase.Create(ctx, &model.Order{
+		CustomerID:       customerID,
+		CustomerName:     request.CustomerName,
+		CustomerEmail:    request.CustomerEmail,
+		Items:            items,
+		CouponCode:       request.CouponCode,
+		ShippingAddress:  model.AddressFromMessage(request.ShippingAddress),
+		BillingAddress:   model.AddressFromMessage(request.BillingAddress),
+		ShippingMethodID: request.ShippingMethodId,
+	}, key)
+	if err != nil {
+		return nil, err
 	}
-	return &order.CreateOrderResponse{Id: result}, nil
+	return &order.CreateOrderResponse{Id: result.ID, AccessToken: result.AccessToken}, nil
 }
 
 func (i *Implementation) GetOrder(ctx context.Context, request *order.GetOrderRequest) (*order.GetOrderResponse, error) {
 	if err := request.ValidateAll(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
 	}
-	result, err := i.orderUseCase.Get(ctx, request.Id)
+	customerID, _ := interceptor.CustomerFromContext(ctx)
+	result, err := i.orderUseCase.Get(ctx, request.Id, customerID, request.AccessToken)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
+	}
+	return &order.GetOrderResponse{Order: customerOrderMessage(result)}, nil
+}
+
+// customerOrderMessage converts an order for its customer, without the administrators' notes on the status changes.
+func customerOrderMessage(o *model.Order) *common.Order {
+	message := o.ConvertToMessage()
+	for _, change := range message.History {
+		change.ChangedBy = ""
+		change.Reason = ""
 	}
-	return &order.GetOrderResponse{Order: result.ConvertToMessage()}, nil
+	return message
 }
 
 func (i *Implementation) ListOrders(ctx context.Context, request *admin.ListOrdersRequest) (*admin.ListOrdersResponse, error) {
 	if err := request.ValidateAll(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
 	}
-	modelOrders, err := i.orderUseCase.List(ctx, request.Limit, request.Offset)
+	after, err := pageCursor[model.OrderCursor](request.PageToken, request.Offset)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	filter, err := orderFilter(request)
+	if err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	modelOrders, next, err := i.orderUseCase.List(ctx, filter, orderSort(request), after, request.Limit, request.Offset)
+	if err != nil {
+		return nil, err
+	}
+	nextToken, err := nextPageToken(next)
+	if err != nil {
+		return nil, err
 	}
 	responseOrders := make([]*common.Order, 0, len(modelOrders))
 	for _, modelOrder := range modelOrders {
 		responseOrders = append(responseOrders, modelOrder.ConvertToMessage())
 	}
-	return &admin.ListOrdersResponse{Orders: responseOrders}, nil
+	return &admin.ListOrdersResponse{Orders: responseOrders, NextPageToken: nextToken}, nil
+}
+
+func orderFilter(request *admin.ListOrdersRequest) (model.OrderFilter, error) {
+	filter := model.OrderFilter{
+		CustomerID:    request.CustomerId,
+		CustomerEmail: request.CustomerEmail,
+		CreatedAfter:  timeOrZero(request.CreatedAfter),
+		CreatedBefore: timeOrZero(request.CreatedBefore),
+		UpdatedAfter:  timeOrZero(request.UpdatedAfter),
+		UpdatedBefore: timeOrZero(request.UpdatedBefore),
+		ProductID:     request.ProductId,
+	}
+	for _, s := range request.Statuses {
+		filter.Statuses = append(filter.Statuses, model.OrderStatus(s))
+	}
+
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		return filter, &model.InvalidArgumentError{Field: "created_after", Description: "must be before created_before"}
+	}
+	if !filter.UpdatedAfter.IsZero() && !filter.UpdatedBefore.IsZero() && !filter.UpdatedAfter.Before(filter.UpdatedBefore) {
+		return filter, &model.InvalidArgumentError{Field: "updated_after", Description: "must be before updated_before"}
+	}
+	return filter, nil
+}
+
+func orderSort(request *admin.ListOrdersRequest) model.OrderSort {
+	var sorting model.OrderSort
+	switch request.SortBy {
+	case admin.OrderSortField_ORDER_SORT_FIELD_UPDATED_AT:
+		sorting.Field = model.OrderSortByUpdatedAt
+	case admin.OrderSortField_ORDER_SORT_FIELD_TOTAL:
+		sorting.Field = model.OrderSortByTotal
+	default:
+		sorting.Field = model.OrderSortByCreatedAt
+	}
+	sorting.Ascending = request.SortOrder == admin.SortOrder_SORT_ORDER_ASC
+	return sorting
+}
+
+// timeOrZero converts an optional timestamp, keeping unset timestamps as zero time.
+func timeOrZero(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// timeOrNil converts an optional timestamp, keeping unset timestamps as nil.
+func timeOrNil(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
 }
 
 func (i *Implementation) UpdateOrderStatus(ctx context.Context, request *admin.UpdateOrderStatusRequest) (*admin.UpdateOrderStatusResponse, error) {
 	if err := request.ValidateAll(); err != nil {
 		i.logger.Warn("validation error", zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(err)
 	}
-	err := i.orderUseCase.UpdateStatus(ctx, request.Id, model.OrderStatus(request.Status))
+	username, _ := interceptor.AdminFromContext(ctx)
+	err := i.orderUseCase.UpdateStatus(ctx, request.Id, model.OrderStatus(request.Status), username, request.Reason)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 	return &admin.UpdateOrderStatusResponse{}, nil
 }
 
+func (i *Implementation) GetOrderHistory(ctx context.Context, request *admin.GetOrderHistoryRequest) (*admin.GetOrderHistoryResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	history, err := i.orderUseCase.History(ctx, request.Id)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]*common.OrderStatusChange, 0, len(history))
+	for _, change := range history {
+		changes = append(changes, change.ConvertToMessage())
+	}
+	return &admin.GetOrderHistoryResponse{History: changes}, nil
+}
+
+func (i *Implementation) GetOrderStatusTransitions(ctx context.Context, request *admin.GetOrderStatusTransitionsRequest) (*admin.GetOrderStatusTransitionsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	current, next, err := i.orderUseCase.NextStatuses(ctx, request.Id)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]common.OrderStatus, 0, len(next))
+	for _, s := range next {
+		allowed = append(allowed, common.OrderStatus(s))
+	}
+	return &admin.GetOrderStatusTransitionsResponse{
+		Status:          common.OrderStatus(current),
+		AllowedStatuses: allowed,
+	}, nil
+}
+
 func New(
 	logger *zap.Logger,
 	productUseCase usecase.ProductUseCase,
+	categoryUseCase usecase.CategoryUseCase,
 	orderUseCase usecase.OrderUseCase,
 	adminUseCase usecase.AdminUseCase,
+	customerUseCase usecase.CustomerUseCase,
+	cartUseCase usecase.CartUseCase,
+	couponUseCase usecase.CouponUseCase,
+	taxUseCase usecase.TaxUseCase,
+	shippingUseCase usecase.ShippingUseCase,
+	paymentUseCase usecase.PaymentUseCase,
 ) *Implementation {
 	return &Implementation{
-		logger:         logger,
-		productUseCase: productUseCase,
-		orderUseCase:   orderUseCase,
-		adminUseCase:   adminUseCase,
+		logger:          logger,
+		productUseCase:  productUseCase,
+		categoryUseCase: categoryUseCase,
+		orderUseCase:    orderUseCase,
+		adminUseCase:    adminUseCase,
+		customerUseCase: customerUseCase,
+		cartUseCase:     cartUseCase,
+		couponUseCase:   couponUseCase,
+		taxUseCase:      taxUseCase,
+		shippingUseCase: shippingUseCase,
+		paymentUseCase:  paymentUseCase,
 	}
 }
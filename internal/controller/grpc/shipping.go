@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/generated/proto/admin"
+	"go_store/generated/proto/order"
+	"go_store/internal/model"
+)
+
+func (i *Implementation) CreateShippingMethod(ctx context.Context, request *admin.CreateShippingMethodRequest) (*admin.CreateShippingMethodResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	rates := make([]model.ShippingRate, 0, len(request.Rates))
+	for _, rate := range request.Rates {
+		rates = append(rates, model.ShippingRate{MinValue: rate.MinValue, Rate: rate.Rate})
+	}
+	id, err := i.shippingUseCase.CreateMethod(ctx, &model.ShippingMethod{
+		Name:        request.Name,
+		Description: request.Description,
+		RateType:    model.ShippingRateTypeFromMessage(request.RateType),
+		FlatRate:    request.FlatRate,
+		Rates:       rates,
+		FreeOver:    request.FreeOver,
+		Countries:   request.Countries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &admin.CreateShippingMethodResponse{Id: id}, nil
+}
+
+func (i *Implementation) ListShippingMethods(ctx context.Context, request *admin.ListShippingMethodsRequest) (*admin.ListShippingMethodsResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.shippingUseCase.ListMethods(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	methods := make([]*admin.ShippingMethod, 0, len(result))
+	for _, m := range result {
+		methods = append(methods, m.ConvertToMessage())
+	}
+	return &admin.ListShippingMethodsResponse{ShippingMethods: methods}, nil
+}
+
+func (i *Implementation) DisableShippingMethod(ctx context.Context, request *admin.DisableShippingMethodRequest) (*admin.DisableShippingMethodResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.shippingUseCase.DisableMethod(ctx, request.Id); err != nil {
+		return nil, err
+	}
+	return &admin.DisableShippingMethodResponse{}, nil
+}
+
+func (i *Implementation) QuoteShipping(ctx context.Context, request *order.QuoteShippingRequest) (*order.QuoteShippingResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	items := make([]model.OrderItem, 0, len(request.Items))
+	for _, item := range request.Items {
+		items = append(items, model.OrderItem{
+			ProductID: item.ProductId,
+			Quantity:  item.Quantity,
+		})
+	}
+	result, err := i.shippingUseCase.Quote(ctx, items, *model.AddressFromMessage(request.ShippingAddress))
+	if err != nil {
+		return nil, err
+	}
+	quotes := make([]*order.ShippingQuote, 0, len(result))
+	for _, q := range result {
+		quotes = append(quotes, &order.ShippingQuote{
+			ShippingMethodId: q.Method.ID,
+			Name:             q.Method.Name,
+			Description:      q.Method.Description,
+			Cost:             q.Cost,
+		})
+	}
+	return &order.QuoteShippingResponse{Quotes: quotes}, nil
+}
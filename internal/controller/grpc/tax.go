@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/generated/proto/admin"
+	"go_store/internal/model"
+)
+
+func (i *Implementation) CreateTaxRule(ctx context.Context, request *admin.CreateTaxRuleRequest) (*admin.CreateTaxRuleResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	id, err := i.taxUseCase.CreateRule(ctx, &model.TaxRule{
+		Country:   request.Country,
+		Region:    request.Region,
+		TaxClass:  request.TaxClass,
+		Name:      request.Name,
+		Rate:      request.Rate,
+		Inclusive: request.Inclusive,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &admin.CreateTaxRuleResponse{Id: id}, nil
+}
+
+func (i *Implementation) ListTaxRules(ctx context.Context, request *admin.ListTaxRulesRequest) (*admin.ListTaxRulesResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	result, err := i.taxUseCase.ListRules(ctx, request.Country)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]*admin.TaxRule, 0, len(result))
+	for _, r := range result {
+		rules = append(rules, r.ConvertToMessage())
+	}
+	return &admin.ListTaxRulesResponse{TaxRules: rules}, nil
+}
+
+func (i *Implementation) DeleteTaxRule(ctx context.Context, request *admin.DeleteTaxRuleRequest) (*admin.DeleteTaxRuleResponse, error) {
+	if err := request.ValidateAll(); err != nil {
+		i.logger.Warn("validation error", zap.Error(err))
+		return nil, invalidArgument(err)
+	}
+	if err := i.taxUseCase.DeleteRule(ctx, request.Id); err != nil {
+		return nil, err
+	}
+	return &admin.DeleteTaxRuleResponse{}, nil
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"encoding/json"
+	"go.uber.org/zap"
+	"go_store/internal/jwtkeys"
+	"net/http"
+)
+
+// JWKSPath is the conventional location of the JSON Web Key Set.
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler publishes the public keys that verify administrator access tokens,
+// so other services can check tokens without sharing a secret.
+func JWKSHandler(logger *zap.Logger, keys *jwtkeys.KeySet) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		err := json.NewEncoder(w).Encode(struct {
+			Keys []jwtkeys.JWK `json:"keys"`
+		}{Keys: keys.JWKS()})
+		if err != nil {
+			logger.Error("can not write jwks response", zap.Error(err))
+		}
+	})
+}
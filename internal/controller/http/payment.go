@@ -0,0 +1,51 @@
+package http
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/payment"
+	"go_store/internal/usecase"
+	"io"
+	"net/http"
+)
+
+// PaymentWebhookPath receives callbacks of the payment provider.
+const PaymentWebhookPath = "/payments/webhook"
+
+// maxWebhookSize limits the body of a callback.
+const maxWebhookSize = 64 << 10
+
+// PaymentWebhookHandler applies payment status changes reported by the provider. Callbacks that fail
+// for reasons other than the request itself get 500, so the provider delivers them again.
+func PaymentWebhookHandler(logger *zap.Logger, payments usecase.PaymentUseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		err = payments.HandleWebhook(r.Context(), r.Header, body)
+		var notFound *model.NotFoundError
+		switch {
+		case err == nil:
+			w.WriteHeader(http.StatusNoContent)
+		case errors.Is(err, payment.ErrInvalidSignature):
+			logger.Warn("payment webhook with invalid signature")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		case errors.As(err, &notFound):
+			logger.Warn("payment webhook for unknown payment", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		default:
+			logger.Error("can not handle payment webhook", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	})
+}
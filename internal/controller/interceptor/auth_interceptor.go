@@ -2,8 +2,10 @@ package interceptor
 
 import (
 	"context"
-	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"go_store/config"
+	"go_store/internal/jwtkeys"
+	"go_store/internal/model"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -11,42 +13,138 @@ import (
 	"strings"
 )
 
-func AuthInterceptor(secret string) grpc.UnaryServerInterceptor {
+const adminServicePrefix = "/store.admin.AdminService/"
+
+type adminKey struct{}
+
+// AdminFromContext returns the username of the authenticated administrator.
+func AdminFromContext(ctx context.Context) (string, bool) {
+	claims, ok := AdminClaimsFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return claims.Subject, true
+}
+
+// AdminClaimsFromContext returns the claims of the access token the request was authenticated with.
+func AdminClaimsFromContext(ctx context.Context) (*model.AdminClaims, bool) {
+	claims, ok := ctx.Value(adminKey{}).(*model.AdminClaims)
+	return claims, ok
+}
+
+// TokenDenylist reports whether an access token was revoked before its expiry.
+type TokenDenylist interface {
+	IsRevoked(claims *model.AdminClaims) bool
+}
+
+var (
+	catalogRoles = []model.AdminRole{model.AdminRoleCatalogManager}
+	orderRoles   = []model.AdminRole{model.AdminRoleOrderManager}
+	anyRole      = []model.AdminRole{model.AdminRoleCatalogManager, model.AdminRoleOrderManager}
+)
+
+// methodRoles lists the roles allowed to call each AdminService method, in addition to superusers.
+// Methods that are not listed are available to superusers only.
+var methodRoles = map[string][]model.AdminRole{
+	"ListOrders":                orderRoles,
+	"UpdateOrderStatus":         orderRoles,
+	"GetOrderHistory":           orderRoles,
+	"GetOrderStatusTransitions": orderRoles,
+	"CreateProduct":             catalogRoles,
+	"UpdateProduct":             catalogRoles,
+	"DeleteProduct":             catalogRoles,
+	"RestoreProduct":            catalogRoles,
+	"ListArchivedProducts":      catalogRoles,
+	"CreateCategory":            catalogRoles,
+	"UpdateCategory":            catalogRoles,
+	"DeleteCategory":            catalogRoles,
+	"SetProductCategories":      catalogRoles,
+	"SetProductStock":           catalogRoles,
+	"AdjustProductStock":        catalogRoles,
+	"CreateCoupon":              catalogRoles,
+	"ListCoupons":               catalogRoles,
+	"DisableCoupon":             catalogRoles,
+	"CreateTaxRule":             catalogRoles,
+	"ListTaxRules":              catalogRoles,
+	"DeleteTaxRule":             catalogRoles,
+	"CreateShippingMethod":      catalogRoles,
+	"ListShippingMethods":       catalogRoles,
+	"DisableShippingMethod":     catalogRoles,
+	"ListPayments":              orderRoles,
+	"CapturePayment":            orderRoles,
+	"VoidPayment":               orderRoles,
+	"RefundPayment":             orderRoles,
+	"ChangePassword":            anyRole,
+	"Logout":                    anyRole,
+	"EnrollTotp":                anyRole,
+	"ConfirmTotp":               anyRole,
+	"DisableTotp":               anyRole,
+}
+
+// publicMethods can be called without an access token.
+var publicMethods = map[string]bool{
+	"Login":         true,
+	"CompleteLogin": true,
+	"RefreshToken":  true,
+}
+
+// bearerToken returns the token from the "authorization: Bearer <token>" header.
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	authHeader := md["authorization"]
+	if len(authHeader) == 0 || !strings.HasPrefix(authHeader[0], "Bearer ") {
+		return "", status.Error(codes.Unauthenticated, "invalid or missing authorization header")
+	}
+
+	return strings.TrimPrefix(authHeader[0], "Bearer "), nil
+}
+
+func AuthInterceptor(cfg *config.Admin, keys *jwtkeys.KeySet, denylist TokenDenylist) grpc.UnaryServerInterceptor {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(keys.Methods()),
+		jwt.WithIssuer(cfg.JWTIssuer),
+		jwt.WithAudience(cfg.JWTAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+
 	return func(
 		ctx context.Context,
 		req interface{},
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		if strings.HasPrefix(info.FullMethod, "/store.admin.AdminService/") &&
-			info.FullMethod != "/store.admin.AdminService/Login" {
-
-			md, ok := metadata.FromIncomingContext(ctx)
-			if !ok {
-				return nil, status.Error(codes.Unauthenticated, "missing metadata")
-			}
-
-			authHeader := md["authorization"]
-			if len(authHeader) == 0 || !strings.HasPrefix(authHeader[0], "Bearer ") {
-				return nil, status.Error(codes.Unauthenticated, "invalid or missing authorization header")
+		method, ok := strings.CutPrefix(info.FullMethod, adminServicePrefix)
+		if ok && !publicMethods[method] {
+			tokenString, err := bearerToken(ctx)
+			if err != nil {
+				return nil, err
 			}
 
-			tokenString := strings.TrimPrefix(authHeader[0], "Bearer ")
-
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-				return []byte(secret), nil
-			})
+			var claims model.AdminClaims
+			token, err := parser.ParseWithClaims(tokenString, &claims, keys.Keyfunc)
 
 			if err != nil {
 				return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
 			}
 
-			if !token.Valid {
+			if !token.Valid || claims.Subject == "" || claims.ID == "" {
 				return nil, status.Error(codes.Unauthenticated, "invalid token")
 			}
+
+			if denylist.IsRevoked(&claims) {
+				return nil, status.Error(codes.Unauthenticated, "token has been revoked")
+			}
+
+			if !claims.HasAnyRole(methodRoles[method]...) {
+				return nil, status.Errorf(codes.PermissionDenied, "%s is not allowed for your roles", method)
+			}
+
+			ctx = context.WithValue(ctx, adminKey{}, &claims)
 		}
 
 		return handler(ctx, req)
@@ -0,0 +1,96 @@
+package interceptor
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"go_store/config"
+	"go_store/internal/jwtkeys"
+	"go_store/internal/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+const (
+	customerServicePrefix = "/store.public.CustomerService/"
+	orderServicePrefix    = "/store.public.OrderService/"
+	cartServicePrefix     = "/store.public.CartService/"
+)
+
+type customerKey struct{}
+
+// CustomerFromContext returns the ID of the authenticated customer.
+func CustomerFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(customerKey{}).(string)
+	return id, ok
+}
+
+// customerPublicMethods of CustomerService can be called without an access token.
+var customerPublicMethods = map[string]bool{
+	"RegisterCustomer":     true,
+	"CustomerLogin":        true,
+	"VerifyEmail":          true,
+	"RequestPasswordReset": true,
+	"ResetPassword":        true,
+}
+
+// CustomerSessions reports whether a customer access token was revoked before its expiry.
+type CustomerSessions interface {
+	IsTokenRevoked(ctx context.Context, claims *model.CustomerClaims) (bool, error)
+}
+
+// CustomerAuthInterceptor authenticates customers. CustomerService requires a customer token except for
+// customerPublicMethods. OrderService and CartService can be used by guests, so the token is checked only if it is sent.
+func CustomerAuthInterceptor(cfg *config.Customer, keys *jwtkeys.KeySet, sessions CustomerSessions) grpc.UnaryServerInterceptor {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(keys.Methods()),
+		jwt.WithIssuer(cfg.JWTIssuer),
+		jwt.WithAudience(cfg.JWTAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		var required bool
+		if method, ok := strings.CutPrefix(info.FullMethod, customerServicePrefix); ok {
+			required = !customerPublicMethods[method]
+		} else if strings.HasPrefix(info.FullMethod, orderServicePrefix) || strings.HasPrefix(info.FullMethod, cartServicePrefix) {
+			required = len(metadata.ValueFromIncomingContext(ctx, "authorization")) > 0
+		}
+
+		if required {
+			tokenString, err := bearerToken(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			var claims model.CustomerClaims
+			token, err := parser.ParseWithClaims(tokenString, &claims, keys.Keyfunc)
+			if err != nil {
+				return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+			}
+			if !token.Valid || claims.Subject == "" {
+				return nil, status.Error(codes.Unauthenticated, "invalid token")
+			}
+
+			revoked, err := sessions.IsTokenRevoked(ctx, &claims)
+			if err != nil {
+				return nil, err
+			}
+			if revoked {
+				return nil, status.Error(codes.Unauthenticated, "token has been revoked")
+			}
+
+			ctx = context.WithValue(ctx, customerKey{}, claims.Subject)
+		}
+
+		return handler(ctx, req)
+	}
+}
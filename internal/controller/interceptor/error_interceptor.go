@@ -0,0 +1,155 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const errorDomain = "go_store"
+
+// ErrorInterceptor translates domain errors returned by handlers into gRPC statuses.
+// Errors without a domain meaning are logged and replaced with a generic Internal status,
+// so database and other internal messages never reach clients.
+func ErrorInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		st := toStatus(err)
+		if st.Code() == codes.Internal {
+			logger.Error("request failed", zap.String("method", info.FullMethod), zap.Error(err))
+		}
+		return nil, st.Err()
+	}
+}
+
+func toStatus(err error) *status.Status {
+	var (
+		notFound          *model.NotFoundError
+		alreadyExists     *model.AlreadyExistsError
+		invalidReference  *model.InvalidReferenceError
+		invalidArgument   *model.InvalidArgumentError
+		outOfStock        *model.OutOfStockError
+		invalidTransition *model.InvalidTransitionError
+		paymentTransition *model.InvalidPaymentTransitionError
+		couponRejected    *model.CouponNotApplicableError
+		loginLocked       *model.LoginLockedError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	case errors.As(err, &notFound):
+		return withDetails(status.New(codes.NotFound, notFound.Error()), &errdetails.ErrorInfo{
+			Domain:   errorDomain,
+			Reason:   "NOT_FOUND",
+			Metadata: map[string]string{"entity": notFound.Entity, "id": notFound.ID},
+		})
+	case errors.As(err, &alreadyExists):
+		return withDetails(status.New(codes.AlreadyExists, alreadyExists.Error()), &errdetails.ErrorInfo{
+			Domain:   errorDomain,
+			Reason:   "ALREADY_EXISTS",
+			Metadata: map[string]string{"entity": alreadyExists.Entity},
+		})
+	case errors.As(err, &invalidReference):
+		return withDetails(status.New(codes.InvalidArgument, invalidReference.Error()), &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       invalidReference.Field,
+				Description: invalidReference.Error(),
+			}},
+		})
+	case errors.As(err, &invalidArgument):
+		return withDetails(status.New(codes.InvalidArgument, invalidArgument.Error()), &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       invalidArgument.Field,
+				Description: invalidArgument.Description,
+			}},
+		})
+	case errors.As(err, &outOfStock):
+		violations := make([]*errdetails.PreconditionFailure_Violation, 0, len(outOfStock.Items))
+		for _, item := range outOfStock.Items {
+			violations = append(violations, &errdetails.PreconditionFailure_Violation{
+				Type:        "STOCK",
+				Subject:     item.ProductID,
+				Description: (&model.OutOfStockError{Items: []model.OutOfStockItem{item}}).Error(),
+			})
+		}
+		return withDetails(status.New(codes.FailedPrecondition, outOfStock.Error()), &errdetails.PreconditionFailure{
+			Violations: violations,
+		})
+	case errors.As(err, &invalidTransition):
+		return withDetails(status.New(codes.FailedPrecondition, invalidTransition.Error()), &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "ORDER_STATUS",
+				Subject:     invalidTransition.OrderID,
+				Description: invalidTransition.Error(),
+			}},
+		})
+	case errors.As(err, &paymentTransition):
+		return withDetails(status.New(codes.FailedPrecondition, paymentTransition.Error()), &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "PAYMENT_STATUS",
+				Subject:     paymentTransition.PaymentID,
+				Description: paymentTransition.Error(),
+			}},
+		})
+	case errors.As(err, &couponRejected):
+		return withDetails(status.New(codes.FailedPrecondition, couponRejected.Error()), &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "COUPON",
+				Subject:     couponRejected.Code,
+				Description: couponRejected.Reason,
+			}},
+		})
+	case errors.As(err, &loginLocked):
+		return withDetails(status.New(codes.ResourceExhausted, loginLocked.Error()), &errdetails.RetryInfo{
+			RetryDelay: durationpb.New(loginLocked.RetryAfter),
+		})
+	case errors.Is(err, model.ErrCategoryHasChildren),
+		errors.Is(err, model.ErrTOTPAlreadyEnabled),
+		errors.Is(err, model.ErrTOTPNotEnrolled),
+		errors.Is(err, model.ErrEmailAlreadyVerified),
+		errors.Is(err, model.ErrCartEmpty),
+		errors.Is(err, model.ErrShippingUnavailable),
+		errors.Is(err, model.ErrOrderNotPayable),
+		errors.Is(err, model.ErrOrderAlreadyPaid),
+		errors.Is(err, model.ErrOrderNotPaid),
+		errors.Is(err, model.ErrOrderHasOpenPayment):
+		return status.New(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, model.ErrOrderStatusChanged), errors.Is(err, model.ErrPaymentChanged):
+		return status.New(codes.Aborted, err.Error())
+	case errors.Is(err, model.ErrInvalidCredentials), errors.Is(err, model.ErrInvalidRefreshToken):
+		return status.New(codes.Unauthenticated, err.Error())
+	default:
+		return status.New(codes.Internal, "internal error")
+	}
+}
+
+// withDetails attaches details to st, falling back to st itself if they can not be encoded.
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) *status.Status {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+	return detailed
+}
@@ -0,0 +1,210 @@
+// Package jwtkeys holds the keys used to sign and verify administrator access tokens.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"go_store/config"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+)
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// KeySet signs tokens with the active key and verifies them with any of the configured keys,
+// selected by the kid header. During a rotation the new key becomes the signing key while the old one
+// stays in the verification keys until the tokens signed with it expire.
+type KeySet struct {
+	signingMethod jwt.SigningMethod
+	signingKey    any
+	signingKeyID  string
+	verification  map[string]verificationKey
+	// hmacSecret verifies tokens without kid: all of them while the secret is the signing key, and after
+	// asymmetric keys were configured only those issued before legacyUntil, if it is set.
+	hmacSecret  []byte
+	legacyUntil time.Time
+}
+
+// Load reads the keys configured in cfg. Without a signing key file tokens are signed with
+// the HMAC secret, as before. With it the secret is ignored unless cfg.JWTAcceptLegacyHS256Until
+// opens a migration window, since anyone who knows the old secret could sign tokens.
+func Load(cfg *config.Admin) (*KeySet, error) {
+	keys := &KeySet{verification: map[string]verificationKey{}}
+
+	if cfg.JWTSigningKeyFile == "" {
+		if cfg.JWTSecret == "" {
+			return nil, errors.New("either ADMIN_JWT_SECRET or ADMIN_JWT_SIGNING_KEY_FILE must be set")
+		}
+		keys.hmacSecret = []byte(cfg.JWTSecret)
+		keys.signingMethod = jwt.SigningMethodHS256
+		keys.signingKey = keys.hmacSecret
+	} else {
+		if cfg.JWTSigningKeyID == "" {
+			return nil, errors.New("ADMIN_JWT_SIGNING_KEY_ID must be set together with ADMIN_JWT_SIGNING_KEY_FILE")
+		}
+		method, private, public, err := loadPrivateKey(cfg.JWTSigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys.signingMethod = method
+		keys.signingKey = private
+		keys.signingKeyID = cfg.JWTSigningKeyID
+		keys.verification[cfg.JWTSigningKeyID] = verificationKey{method: method, key: public}
+
+		if !cfg.JWTAcceptLegacyHS256Until.IsZero() {
+			if cfg.JWTSecret == "" {
+				return nil, errors.New("ADMIN_JWT_ACCEPT_LEGACY_HS256_UNTIL requires ADMIN_JWT_SECRET")
+			}
+			keys.hmacSecret = []byte(cfg.JWTSecret)
+			keys.legacyUntil = cfg.JWTAcceptLegacyHS256Until
+		}
+	}
+
+	for kid, path := range cfg.JWTVerificationKeyFiles {
+		if _, ok := keys.verification[kid]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", kid)
+		}
+		method, public, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		keys.verification[kid] = verificationKey{method: method, key: public}
+	}
+	return keys, nil
+}
+
+func loadPrivateKey(path string) (jwt.SigningMethod, crypto.PrivateKey, crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return jwt.SigningMethodRS256, key, &key.PublicKey, nil
+	}
+	if key, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		if private, ok := key.(ed25519.PrivateKey); ok {
+			return jwt.SigningMethodEdDSA, private, private.Public(), nil
+		}
+	}
+	return nil, nil, nil, fmt.Errorf("%s: not an RSA or Ed25519 private key in PEM format", path)
+}
+
+func loadPublicKey(path string) (jwt.SigningMethod, crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return jwt.SigningMethodRS256, key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return jwt.SigningMethodEdDSA, key, nil
+	}
+	return nil, nil, fmt.Errorf("%s: not an RSA or Ed25519 public key in PEM format", path)
+}
+
+// Sign signs the claims with the active key.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signingMethod, claims)
+	if k.signingKeyID != "" {
+		token.Header["kid"] = k.signingKeyID
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// Methods returns the signing algorithms accepted by Keyfunc.
+func (k *KeySet) Methods() []string {
+	methods := map[string]bool{}
+	if k.hmacSecret != nil {
+		methods[jwt.SigningMethodHS256.Alg()] = true
+	}
+	for _, key := range k.verification {
+		methods[key.method.Alg()] = true
+	}
+	result := make([]string, 0, len(methods))
+	for method := range methods {
+		result = append(result, method)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Keyfunc returns the key to verify the token with. Tokens with kid must be signed with the
+// algorithm of that key, tokens without kid are verified with the HMAC secret.
+func (k *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		if k.hmacSecret == nil || token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("missing kid")
+		}
+		if !k.legacyUntil.IsZero() && !k.isLegacy(token) {
+			return nil, errors.New("token without kid is no longer accepted")
+		}
+		return k.hmacSecret, nil
+	}
+	key, ok := k.verification[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for kid %q", token.Method.Alg(), kid)
+	}
+	return key.key, nil
+}
+
+// isLegacy reports whether a token without kid falls in the migration window: it was issued before
+// legacyUntil, which has not passed yet.
+func (k *KeySet) isLegacy(token *jwt.Token) bool {
+	if !time.Now().Before(k.legacyUntil) {
+		return false
+	}
+	issuedAt, err := token.Claims.GetIssuedAt()
+	return err == nil && issuedAt != nil && issuedAt.Before(k.legacyUntil)
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 keys.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS returns the public verification keys. The HMAC secret is never published.
+func (k *KeySet) JWKS() []JWK {
+	keys := make([]JWK, 0, len(k.verification))
+	for kid, key := range k.verification {
+		jwk := JWK{KeyID: kid, Use: "sig", Algorithm: key.method.Alg()}
+		switch public := key.key.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+	return keys
+}
@@ -0,0 +1,66 @@
+// Package logging contains zap helpers shared by the application.
+package logging
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are parts of field names whose values must never be written to logs.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "recovery_code", "otp"}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redact(fields []zapcore.Field) []zapcore.Field {
+	var result []zapcore.Field
+	for i, field := range fields {
+		if !isSensitive(field.Key) {
+			continue
+		}
+		if result == nil {
+			result = append(make([]zapcore.Field, 0, len(fields)), fields...)
+		}
+		result[i] = zap.String(field.Key, redacted)
+	}
+	if result == nil {
+		return fields
+	}
+	return result
+}
+
+// redactCore replaces values of fields with sensitive names, such as "password" or "refresh_token".
+type redactCore struct {
+	zapcore.Core
+}
+
+// Redact wraps core so that credentials passed as log fields are replaced with a placeholder.
+// Use it with zap.WrapCore.
+func Redact(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redact(fields))}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, redact(fields))
+}
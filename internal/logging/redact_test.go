@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     zap.Field
+		wantValue any
+	}{
+		{"password", zap.String("password", "hunter2"), redacted},
+		{"name containing password", zap.String("new_password", "hunter2"), redacted},
+		{"refresh token", zap.String("refresh_token", "abc"), redacted},
+		{"uppercase name", zap.String("Authorization", "Bearer abc"), redacted},
+		{"secret", zap.String("client_secret", "abc"), redacted},
+		{"recovery code", zap.String("recovery_code", "abcd-efgh"), redacted},
+		{"one-time password", zap.String("otp", "123456"), redacted},
+		{"value of another type", zap.Int("token", 42), redacted},
+		{"username is kept", zap.String("username", "admin"), "admin"},
+		{"order ID is kept", zap.String("order_id", "1"), "1"},
+		{"number is kept", zap.Int64("attempts", 3), int64(3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			zap.New(Redact(core)).Info("message", tt.field)
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("%d entries logged, want 1", len(entries))
+			}
+			if got := entries[0].ContextMap()[tt.field.Key]; got != tt.wantValue {
+				t.Errorf("%s = %v, want %v", tt.field.Key, got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestRedactWith(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(Redact(core)).With(zap.String("access_token", "abc"), zap.String("username", "admin"))
+	logger.Info("message", zap.String("password", "hunter2"))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("%d entries logged, want 1", len(entries))
+	}
+	want := map[string]any{"access_token": redacted, "username": "admin", "password": redacted}
+	got := entries[0].ContextMap()
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+}
+
+func TestRedactKeepsFields(t *testing.T) {
+	fields := []zapcore.Field{zap.String("password", "hunter2"), zap.String("username", "admin")}
+	redact(fields)
+	if fields[0].String != "hunter2" {
+		t.Error("redact changed the fields of the caller")
+	}
+}
+
+func TestRedactLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	zap.New(Redact(core)).Debug("message", zap.String("password", "hunter2"))
+	if logs.Len() != 0 {
+		t.Errorf("%d entries logged below the level of the core", logs.Len())
+	}
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"os"
+	"sync"
+	"time"
+)
+
+type logMailer struct {
+	logger *zap.Logger
+}
+
+// NewLog logs the recipient and subject of messages instead of sending them. Bodies, which carry links
+// with tokens, are not logged; use NewFile to read them during development.
+func NewLog(logger *zap.Logger) Mailer {
+	return &logMailer{logger: logger}
+}
+
+func (l *logMailer) Send(_ context.Context, message Message) error {
+	l.logger.Info("email",
+		zap.String("to", message.To),
+		zap.String("subject", message.Subject),
+	)
+	return nil
+}
+
+type fileMailer struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFile appends messages to a file instead of sending them, for development and tests.
+func NewFile(path string) Mailer {
+	return &fileMailer{path: path}
+}
+
+func (f *fileMailer) Send(_ context.Context, message Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), message.To, message.Subject, message.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
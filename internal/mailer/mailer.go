@@ -0,0 +1,44 @@
+// Package mailer sends emails to customers.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"go_store/config"
+	"strings"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// New creates the mailer selected by cfg.Driver.
+func New(cfg *config.Mail, logger *zap.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case config.MailDriverSMTP:
+		return NewSMTP(cfg), nil
+	case config.MailDriverFile:
+		return NewFile(cfg.File), nil
+	case config.MailDriverLog:
+		return NewLog(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// checkHeader rejects header values with line breaks, which could inject headers into the message.
+func checkHeader(name, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%s must not contain line breaks", name)
+	}
+	return nil
+}
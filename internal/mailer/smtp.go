@@ -0,0 +1,110 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"go_store/config"
+	"mime"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+type smtpMailer struct {
+	host string
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP sends messages through an SMTP server. STARTTLS is used if the server supports it;
+// credentials are sent only over TLS or to localhost.
+func NewSMTP(cfg *config.Mail) Mailer {
+	m := &smtpMailer{
+		host: cfg.SMTPHost,
+		addr: net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort),
+		from: cfg.From,
+	}
+	if cfg.SMTPUsername != "" {
+		m.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return m
+}
+
+func (s *smtpMailer) Send(ctx context.Context, message Message) error {
+	if err := checkHeader("to", message.To); err != nil {
+		return err
+	}
+	if err := checkHeader("subject", message.Subject); err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	body.WriteString("From: " + s.from + "\r\n")
+	body.WriteString("To: " + message.To + "\r\n")
+	body.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", message.Subject) + "\r\n")
+	body.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	body.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(message.Body)
+
+	return s.send(ctx, message.To, body.Bytes())
+}
+
+// send does what smtp.SendMail does, but the connection is bound to ctx.
+func (s *smtpMailer) send(ctx context.Context, to string, body []byte) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+	// Cancellation interrupts the exchange the same way the deadline does.
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err = c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(s.from); err != nil {
+		return err
+	}
+	if err = c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
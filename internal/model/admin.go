@@ -0,0 +1,137 @@
+package model
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"go_store/generated/proto/admin"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// AdminRole limits the AdminService methods an administrator can call.
+type AdminRole string
+
+const (
+	AdminRoleSuperuser      AdminRole = "superuser"
+	AdminRoleCatalogManager AdminRole = "catalog_manager"
+	AdminRoleOrderManager   AdminRole = "order_manager"
+)
+
+var adminRoleMessages = map[AdminRole]admin.AdminRole{
+	AdminRoleSuperuser:      admin.AdminRole_ADMIN_ROLE_SUPERUSER,
+	AdminRoleCatalogManager: admin.AdminRole_ADMIN_ROLE_CATALOG_MANAGER,
+	AdminRoleOrderManager:   admin.AdminRole_ADMIN_ROLE_ORDER_MANAGER,
+}
+
+// AdminRoleFromMessage converts a role from the API. It returns an empty role for unknown values.
+func AdminRoleFromMessage(role admin.AdminRole) AdminRole {
+	for r, message := range adminRoleMessages {
+		if message == role {
+			return r
+		}
+	}
+	return ""
+}
+
+type AdminUser struct {
+	ID           string      `json:"id"`
+	Username     string      `json:"username"`
+	PasswordHash string      `json:"-"`
+	Roles        []AdminRole `json:"roles"`
+	CreatedAt    time.Time   `json:"created_at"`
+	DisabledAt   *time.Time  `json:"disabled_at"`
+	// TOTPSecret is set once enrollment starts, TOTPEnabledAt once it is confirmed.
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at"`
+}
+
+// TOTPEnabled reports whether the administrator has to enter a one-time code on login.
+func (a *AdminUser) TOTPEnabled() bool {
+	return a.TOTPEnabledAt != nil
+}
+
+func (a *AdminUser) ConvertToMessage() *admin.AdminUser {
+	roles := make([]admin.AdminRole, 0, len(a.Roles))
+	for _, role := range a.Roles {
+		roles = append(roles, adminRoleMessages[role])
+	}
+	message := &admin.AdminUser{
+		Id:          a.ID,
+		Username:    a.Username,
+		Roles:       roles,
+		CreatedAt:   timestamppb.New(a.CreatedAt),
+		TotpEnabled: a.TOTPEnabled(),
+	}
+	if a.DisabledAt != nil {
+		message.DisabledAt = timestamppb.New(*a.DisabledAt)
+	}
+	return message
+}
+
+// AdminTokens are issued on login and on refresh. If the administrator has two-factor authentication,
+// login issues only a challenge, and the tokens are issued once it is completed with a code.
+type AdminTokens struct {
+	Challenge          string
+	ChallengeExpiresAt time.Time
+
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// LoginChallenge is the second step of login for administrators with two-factor authentication.
+type LoginChallenge struct {
+	ID        string
+	AdminID   string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// TOTPEnrollment is the secret shown to the administrator to set up an authenticator app.
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// RefreshToken is a server-side record of an issued refresh token. Only the hash of the token is stored.
+type RefreshToken struct {
+	ID      string
+	AdminID string
+	// FamilyID is shared by all tokens issued by rotating the same login.
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// RevokedToken is an access token revoked before its expiry, identified by jti.
+type RevokedToken struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+// SessionRevocation revokes all access tokens of the administrator issued before RevokedAt.
+type SessionRevocation struct {
+	Username  string
+	RevokedAt time.Time
+}
+
+// AdminClaims are the claims of an administrator access token. Subject is the username.
+type AdminClaims struct {
+	jwt.RegisteredClaims
+	Roles []AdminRole `json:"roles"`
+}
+
+// HasAnyRole reports whether the token grants any of the roles. Superusers have every role.
+func (c *AdminClaims) HasAnyRole(roles ...AdminRole) bool {
+	for _, have := range c.Roles {
+		if have == AdminRoleSuperuser {
+			return true
+		}
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"go_store/generated/proto/cart"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// CartItem is priced with the current product price every time the cart is read.
+type CartItem struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int32  `json:"quantity"`
+	UnitPrice   int64  `json:"unit_price"`
+	LineTotal   int64  `json:"line_total"`
+	// Available is false if the product was archived or there is not enough of it in stock.
+	Available bool `json:"available"`
+}
+
+type Cart struct {
+	ID string `json:"id"`
+	// CustomerID is empty for guest carts.
+	CustomerID string     `json:"customer_id"`
+	Items      []CartItem `json:"items"`
+	Subtotal   int64      `json:"subtotal"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CalculateTotals fills line totals and the subtotal from the unit prices of the items.
+func (c *Cart) CalculateTotals() {
+	c.Subtotal = 0
+	for i := range c.Items {
+		c.Items[i].LineTotal = c.Items[i].UnitPrice * int64(c.Items[i].Quantity)
+		c.Subtotal += c.Items[i].LineTotal
+	}
+}
+
+// OrderItems returns the items to order. Prices are taken by the order itself.
+func (c *Cart) OrderItems() []OrderItem {
+	items := make([]OrderItem, 0, len(c.Items))
+	for _, item := range c.Items {
+		items = append(items, OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return items
+}
+
+func (c *Cart) ConvertToMessage() *cart.Cart {
+	items := make([]*cart.CartItem, 0, len(c.Items))
+	for _, item := range c.Items {
+		items = append(items, &cart.CartItem{
+			ProductId:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			LineTotal:   item.LineTotal,
+			Available:   item.Available,
+		})
+	}
+	return &cart.Cart{
+		Id:         c.ID,
+		CustomerId: c.CustomerID,
+		Items:      items,
+		Subtotal:   c.Subtotal,
+		CreatedAt:  timestamppb.New(c.CreatedAt),
+		UpdatedAt:  timestamppb.New(c.UpdatedAt),
+	}
+}
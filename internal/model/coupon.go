@@ -0,0 +1,134 @@
+package model
+
+import (
+	"fmt"
+	"go_store/generated/proto/admin"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+type CouponType string
+
+const (
+	CouponPercentage  CouponType = "percentage"
+	CouponFixedAmount CouponType = "fixed_amount"
+	CouponFreeItem    CouponType = "free_item"
+)
+
+var couponTypeMessages = map[CouponType]admin.CouponType{
+	CouponPercentage:  admin.CouponType_COUPON_TYPE_PERCENTAGE,
+	CouponFixedAmount: admin.CouponType_COUPON_TYPE_FIXED_AMOUNT,
+	CouponFreeItem:    admin.CouponType_COUPON_TYPE_FREE_ITEM,
+}
+
+// CouponTypeFromMessage converts a coupon type from the API. It returns an empty type for unknown values.
+func CouponTypeFromMessage(couponType admin.CouponType) CouponType {
+	for t, message := range couponTypeMessages {
+		if message == couponType {
+			return t
+		}
+	}
+	return ""
+}
+
+type Coupon struct {
+	ID   string     `json:"id"`
+	Code string     `json:"code"`
+	Type CouponType `json:"type"`
+	// Value is the percent for percentage coupons and the amount for fixed amount coupons.
+	Value         int64  `json:"value"`
+	FreeProductID string `json:"free_product_id"`
+	MinOrderValue int64  `json:"min_order_value"`
+	// StartsAt and EndsAt limit when the coupon can be used, nil means no limit.
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	// MaxUses and MaxUsesPerCustomer are unlimited if zero.
+	MaxUses            int32      `json:"max_uses"`
+	MaxUsesPerCustomer int32      `json:"max_uses_per_customer"`
+	Uses               int32      `json:"uses"`
+	CreatedAt          time.Time  `json:"created_at"`
+	DisabledAt         *time.Time `json:"disabled_at"`
+}
+
+// CouponUsage counts orders placed with a coupon. Cancelled orders are not counted.
+type CouponUsage struct {
+	Total int32
+	// Customer counts orders of the same customer account or, for guests, the same email.
+	Customer int32
+}
+
+// Apply checks that the coupon can be used for the order and sets its discount and total.
+// Unit prices of the items must be filled in.
+func (c *Coupon) Apply(order *Order, usage CouponUsage, now time.Time) error {
+	notApplicable := func(reason string) error {
+		return &CouponNotApplicableError{Code: c.Code, Reason: reason}
+	}
+	switch {
+	case c.DisabledAt != nil:
+		return notApplicable("coupon is disabled")
+	case c.StartsAt != nil && now.Before(*c.StartsAt):
+		return notApplicable("coupon is not active yet")
+	case c.EndsAt != nil && !now.Before(*c.EndsAt):
+		return notApplicable("coupon has expired")
+	case c.MaxUses > 0 && usage.Total >= c.MaxUses:
+		return notApplicable("coupon usage limit is reached")
+	case c.MaxUsesPerCustomer > 0 && usage.Customer >= c.MaxUsesPerCustomer:
+		return notApplicable("coupon was already used by the customer")
+	}
+
+	order.Discount = 0
+	order.CalculateTotals()
+	if order.Subtotal < c.MinOrderValue {
+		return notApplicable(fmt.Sprintf("order subtotal must be at least %d", c.MinOrderValue))
+	}
+
+	var discount int64
+	switch c.Type {
+	case CouponPercentage:
+		discount = order.Subtotal * c.Value / 100
+	case CouponFixedAmount:
+		discount = c.Value
+	case CouponFreeItem:
+		found := false
+		for _, item := range order.Items {
+			if item.ProductID == c.FreeProductID {
+				discount, found = item.UnitPrice, true
+				break
+			}
+		}
+		if !found {
+			return notApplicable("order does not contain the free product")
+		}
+	}
+
+	order.CouponID = c.ID
+	order.CouponCode = c.Code
+	order.Discount = min(discount, order.Subtotal)
+	order.CalculateTotals()
+	return nil
+}
+
+func (c *Coupon) ConvertToMessage() *admin.Coupon {
+	message := &admin.Coupon{
+		Id:                 c.ID,
+		Code:               c.Code,
+		Type:               couponTypeMessages[c.Type],
+		Value:              c.Value,
+		FreeProductId:      c.FreeProductID,
+		MinOrderValue:      c.MinOrderValue,
+		MaxUses:            c.MaxUses,
+		MaxUsesPerCustomer: c.MaxUsesPerCustomer,
+		Uses:               c.Uses,
+		CreatedAt:          timestamppb.New(c.CreatedAt),
+	}
+	if c.StartsAt != nil {
+		message.StartsAt = timestamppb.New(*c.StartsAt)
+	}
+	if c.EndsAt != nil {
+		message.EndsAt = timestamppb.New(*c.EndsAt)
+	}
+	if c.DisabledAt != nil {
+		message.DisabledAt = timestamppb.New(*c.DisabledAt)
+	}
+	return message
+}
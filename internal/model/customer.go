@@ -0,0 +1,73 @@
+package model
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"go_store/generated/proto/customer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+const (
+	CustomerFieldEmail = "email"
+	CustomerFieldName  = "name"
+)
+
+type Customer struct {
+	ID string `json:"id"`
+	// Email is stored in lower case and is unique.
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// EmailVerifiedAt is reset when the email changes.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// SessionsRevokedAt is set when the password is reset or the email changes. Access tokens issued before it are rejected.
+	SessionsRevokedAt *time.Time `json:"-"`
+}
+
+func (c *Customer) ConvertToMessage() *customer.Customer {
+	return &customer.Customer{
+		Id:            c.ID,
+		Email:         c.Email,
+		Name:          c.Name,
+		CreatedAt:     timestamppb.New(c.CreatedAt),
+		UpdatedAt:     timestamppb.New(c.UpdatedAt),
+		EmailVerified: c.EmailVerifiedAt != nil,
+	}
+}
+
+// CustomerToken is issued on customer login. Customers log in again once it expires.
+type CustomerToken struct {
+	CustomerID  string
+	AccessToken string
+	ExpiresAt   time.Time
+}
+
+// CustomerClaims are the claims of a customer access token. Subject is the customer ID.
+type CustomerClaims struct {
+	jwt.RegisteredClaims
+}
+
+// EmailTokenPurpose is the action a token sent by email allows.
+type EmailTokenPurpose string
+
+const (
+	EmailTokenVerifyEmail   EmailTokenPurpose = "verify_email"
+	EmailTokenResetPassword EmailTokenPurpose = "reset_password"
+)
+
+// EmailToken is a server-side record of a token sent by email. ID is the jti of the token.
+type EmailToken struct {
+	ID         string
+	CustomerID string
+	Purpose    EmailTokenPurpose
+	ExpiresAt  time.Time
+}
+
+// EmailTokenClaims are the claims of a token sent by email. Subject is the customer ID and Email
+// is the address the token was sent to, so a verification token is void once the email changes.
+type EmailTokenClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+}
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrOrderStatusChanged is returned when an order status was changed by someone else in the meantime.
+	ErrOrderStatusChanged = errors.New("order status was changed concurrently")
+
+	// ErrPaymentChanged is returned when a payment was changed by someone else in the meantime.
+	ErrPaymentChanged = errors.New("payment was changed concurrently")
+
+	// ErrCategoryCycle is returned when a category would become its own ancestor.
+	ErrCategoryCycle = &InvalidArgumentError{
+		Field:       "parent_id",
+		Description: "category can not be moved under itself or its subcategory",
+	}
+
+	// ErrCategoryHasChildren is returned when a category with subcategories is deleted.
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+
+	// ErrPageTokenMismatch is returned when a page token is used with a different sort order.
+	ErrPageTokenMismatch = &InvalidArgumentError{
+		Field:       "page_token",
+		Description: "page_token was issued for a different sort order",
+	}
+
+	// ErrInvalidCredentials is returned when login fails.
+	ErrInvalidCredentials = errors.New("wrong credentials")
+
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or already used.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrTOTPAlreadyEnabled is returned when enrolling an administrator that already has two-factor authentication.
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrTOTPNotEnrolled is returned when confirming or disabling two-factor authentication that was not set up.
+	ErrTOTPNotEnrolled = errors.New("two-factor authentication is not enrolled")
+
+	// ErrEmailAlreadyVerified is returned when requesting verification of an email that is verified.
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+
+	// ErrCartEmpty is returned when checking out a cart without items.
+	ErrCartEmpty = errors.New("cart is empty")
+
+	// ErrShippingUnavailable is returned when the chosen shipping method can not ship the order.
+	ErrShippingUnavailable = errors.New("shipping method is not available for the order")
+
+	// ErrOrderNotPayable is returned when paying an order that is not pending or has nothing to pay.
+	ErrOrderNotPayable = errors.New("order can not be paid")
+
+	// ErrOrderAlreadyPaid is returned when paying an order that has a payment which neither failed nor was voided.
+	ErrOrderAlreadyPaid = errors.New("order already has a payment")
+
+	// ErrOrderNotPaid is returned when processing an order whose total is neither authorized nor captured.
+	ErrOrderNotPaid = errors.New("order is not paid")
+
+	// ErrOrderHasOpenPayment is returned when cancelling an order with a payment that is pending, authorized
+	// or captured and not fully refunded. Authorized payments have to be voided and captured ones refunded first.
+	ErrOrderHasOpenPayment = errors.New("order has a payment that must be voided or refunded first")
+
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is used again.
+	ErrRefreshTokenReused = fmt.Errorf("%w: token was already used", ErrInvalidRefreshToken)
+)
+
+// NotFoundError is returned when a requested entity does not exist.
+type NotFoundError struct {
+	Entity string
+	ID     string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Entity, e.ID)
+}
+
+// AlreadyExistsError is returned when an entity conflicts with an existing one.
+type AlreadyExistsError struct {
+	Entity string
+}
+
+func (e *AlreadyExistsError) Error() string {
+	return e.Entity + " already exists"
+}
+
+// InvalidReferenceError is returned when a request refers to an entity that does not exist.
+type InvalidReferenceError struct {
+	// Field is the request field holding the reference.
+	Field  string
+	Entity string
+	ID     string
+}
+
+func (e *InvalidReferenceError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("%s: %s does not exist", e.Field, e.Entity)
+	}
+	return fmt.Sprintf("%s: %s %s does not exist", e.Field, e.Entity, e.ID)
+}
+
+// InvalidArgumentError is returned when a request field is valid on its own but can not be applied.
+type InvalidArgumentError struct {
+	Field       string
+	Description string
+}
+
+func (e *InvalidArgumentError) Error() string {
+	return e.Field + ": " + e.Description
+}
+
+// InvalidTransitionError is returned when an order can not be moved to the requested status.
+type InvalidTransitionError struct {
+	OrderID string
+	From    OrderStatus
+	To      OrderStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("can not change order status from %s to %s", e.From, e.To)
+}
+
+// InvalidPaymentTransitionError is returned when an operation would move a payment to a status
+// it can not have after its current one.
+type InvalidPaymentTransitionError struct {
+	PaymentID string
+	From      PaymentStatus
+	To        PaymentStatus
+}
+
+func (e *InvalidPaymentTransitionError) Error() string {
+	return fmt.Sprintf("can not change payment status from %s to %s", e.From, e.To)
+}
+
+// OutOfStockItem describes a product that does not have enough stock to be reserved.
+type OutOfStockItem struct {
+	ProductID string
+	Requested int64
+	Available int32
+}
+
+// OutOfStockError is returned when one or more products can not be reserved.
+type OutOfStockError struct {
+	Items []OutOfStockItem
+}
+
+func (e *OutOfStockError) Error() string {
+	parts := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		parts = append(parts, fmt.Sprintf("%s (requested %d, available %d)", item.ProductID, item.Requested, item.Available))
+	}
+	return "out of stock: " + strings.Join(parts, ", ")
+}
+
+// CouponNotApplicableError is returned when a coupon exists but can not be used for the order.
+type CouponNotApplicableError struct {
+	Code   string
+	Reason string
+}
+
+func (e *CouponNotApplicableError) Error() string {
+	return fmt.Sprintf("coupon %s can not be applied: %s", e.Code, e.Reason)
+}
+
+// LoginLockedError is returned when login is temporarily blocked after too many failures.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
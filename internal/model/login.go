@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// LockoutPolicy defines when repeated login failures lock a username or a client address.
+type LockoutPolicy struct {
+	// MaxFailures is the number of failures allowed before the first lockout.
+	MaxFailures int
+	// Lockout is the duration of the first lockout. Every next failure doubles it, up to MaxLockout.
+	Lockout    time.Duration
+	MaxLockout time.Duration
+}
+
+// LockoutAfter returns how long to lock after the given number of consecutive failures.
+func (p LockoutPolicy) LockoutAfter(failures int) time.Duration {
+	if failures < p.MaxFailures || p.Lockout <= 0 {
+		return 0
+	}
+	lockout := p.Lockout
+	for i := p.MaxFailures; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, p.MaxLockout)
+}
@@ -16,26 +16,288 @@ const (
 	CANCELLED
 )
 
+// orderStatusTransitions lists the statuses an order can be moved to from each status.
+// COMPLETED and CANCELLED are final.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	PENDING:    {PROCESSING, CANCELLED},
+	PROCESSING: {COMPLETED, CANCELLED},
+}
+
+func (s OrderStatus) String() string {
+	switch s {
+	case PENDING:
+		return "PENDING"
+	case PROCESSING:
+		return "PROCESSING"
+	case COMPLETED:
+		return "COMPLETED"
+	case CANCELLED:
+		return "CANCELLED"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+// NextStatuses returns the statuses an order with status s can be moved to.
+func (s OrderStatus) NextStatuses() []OrderStatus {
+	return orderStatusTransitions[s]
+}
+
+// CanTransitionTo reports whether an order with status s can be moved to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, status := range orderStatusTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Product fields that can be changed with a partial update.
+const (
+	ProductFieldName        = "name"
+	ProductFieldDescription = "description"
+	ProductFieldPrice       = "price"
+	ProductFieldTaxClass    = "tax_class"
+	ProductFieldWeight      = "weight"
+)
+
+// DefaultTaxClass is assigned to products created without a tax class.
+const DefaultTaxClass = "standard"
+
 type Product struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Price       int64  `json:"price"`
+	Stock       int32  `json:"stock"`
+	TaxClass    string `json:"tax_class"`
+	// Weight is in grams, zero for products that are not shipped.
+	Weight      int32      `json:"weight"`
+	CategoryIDs []string   `json:"category_ids"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+}
+
+// ProductFilter narrows down the list of products.
+type ProductFilter struct {
+	// CategoryID limits the list to products assigned to the category. Empty means any category.
+	CategoryID string
+	// IncludeDescendants also matches products assigned to subcategories of CategoryID.
+	IncludeDescendants bool
+}
+
+// ProductCursor points at the last product of a page, in ListProducts order.
+type ProductCursor struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// ProductSearchResult is a product found by a full-text query.
+type ProductSearchResult struct {
+	Product Product
+	Rank    float32
+	// NameHighlight and DescriptionHighlight contain fragments with matched words wrapped in <b></b>.
+	NameHighlight        string
+	DescriptionHighlight string
+}
+
+// Category fields that can be changed with a partial update.
+const (
+	CategoryFieldName        = "name"
+	CategoryFieldDescription = "description"
+	CategoryFieldParentID    = "parent_id"
+)
+
+type Category struct {
+	ID string `json:"id"`
+	// ParentID is empty for root categories.
+	ParentID    string `json:"parent_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (c *Category) ConvertToMessage() *common.Category {
+	return &common.Category{
+		Id:          c.ID,
+		ParentId:    c.ParentID,
+		Name:        c.Name,
+		Description: c.Description,
+	}
 }
 
 type OrderItem struct {
 	ProductID string `json:"product_id"`
 	Quantity  int32  `json:"quantity"`
+	UnitPrice int64  `json:"unit_price"`
+	LineTotal int64  `json:"line_total"`
+	// TaxClass and UnitWeight of the product when the order was placed.
+	TaxClass   string `json:"tax_class"`
+	UnitWeight int32  `json:"unit_weight"`
+}
+
+type OrderStatusChange struct {
+	OldStatus OrderStatus `json:"old_status"`
+	NewStatus OrderStatus `json:"new_status"`
+	ChangedBy string      `json:"changed_by"`
+	Reason    string      `json:"reason"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+func (c *OrderStatusChange) ConvertToMessage() *common.OrderStatusChange {
+	return &common.OrderStatusChange{
+		OldStatus: common.OrderStatus(c.OldStatus),
+		NewStatus: common.OrderStatus(c.NewStatus),
+		ChangedBy: c.ChangedBy,
+		Reason:    c.Reason,
+		ChangedAt: timestamppb.New(c.ChangedAt),
+	}
 }
 
 type Order struct {
-	ID            string      `json:"id"`
+	ID string `json:"id"`
+	// CustomerID is empty for guest orders.
+	CustomerID    string      `json:"customer_id"`
 	CustomerName  string      `json:"customer_name"`
 	CustomerEmail string      `json:"customer_email"`
 	Items         []OrderItem `json:"items"`
 	Status        OrderStatus `json:"status"`
-	CreatedAt     time.Time   `json:"created_at"`
-	UpdatedAt     time.Time   `json:"updated_at"`
+	Subtotal      int64       `json:"subtotal"`
+	// CouponID and CouponCode are set if a coupon was applied; Discount is subtracted from the subtotal.
+	CouponID   string `json:"coupon_id"`
+	CouponCode string `json:"coupon_code"`
+	Discount   int64  `json:"discount"`
+	// ShippingAddress and BillingAddress are nil if the order was placed without them.
+	ShippingAddress *Address `json:"shipping_address"`
+	BillingAddress  *Address `json:"billing_address"`
+	// ShippingMethodID is empty if no shipping method was chosen.
+	ShippingMethodID   string `json:"shipping_method_id"`
+	ShippingMethodName string `json:"shipping_method_name"`
+	ShippingCost       int64  `json:"shipping_cost"`
+	// TaxCountry and TaxRegion keep the location the TaxLines were calculated for. Tax is the sum of the tax lines.
+	TaxCountry string              `json:"tax_country"`
+	TaxRegion  string              `json:"tax_region"`
+	TaxLines   []TaxLine           `json:"tax_lines"`
+	Tax        int64               `json:"tax"`
+	Total      int64               `json:"total"`
+	History    []OrderStatusChange `json:"history"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	// CartID is set when the order is placed from a cart; Items are then taken from it. It is not stored.
+	CartID string `json:"-"`
+}
+
+func (p *Product) ConvertToMessage() *common.Product {
+	message := &common.Product{
+		Id:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Stock:       p.Stock,
+		TaxClass:    p.TaxClass,
+		Weight:      p.Weight,
+		CategoryIds: p.CategoryIDs,
+	}
+	if p.ArchivedAt != nil {
+		message.ArchivedAt = timestamppb.New(*p.ArchivedAt)
+	}
+	return message
+}
+
+// OrderFilter narrows down the list of orders. Zero values do not filter.
+type OrderFilter struct {
+	Statuses      []OrderStatus
+	CustomerID    string
+	CustomerEmail string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	// ProductID matches orders containing the product.
+	ProductID string
+}
+
+type OrderSortField int
+
+const (
+	OrderSortByCreatedAt OrderSortField = iota
+	OrderSortByUpdatedAt
+	OrderSortByTotal
+)
+
+// OrderSort defines the order of ListOrders. The zero value sorts by creation time, newest first.
+type OrderSort struct {
+	Field     OrderSortField
+	Ascending bool
+}
+
+// OrderCursor points at the last order of a page, in ListOrders order.
+type OrderCursor struct {
+	Sort  OrderSort `json:"sort"`
+	Time  time.Time `json:"time,omitzero"`
+	Total int64     `json:"total,omitempty"`
+	ID    string    `json:"id"`
+}
+
+// Cursor returns the cursor pointing at the order in the given sort order.
+func (o *Order) Cursor(sort OrderSort) *OrderCursor {
+	cursor := &OrderCursor{Sort: sort, ID: o.ID}
+	switch sort.Field {
+	case OrderSortByUpdatedAt:
+		cursor.Time = o.UpdatedAt
+	case OrderSortByTotal:
+		cursor.Total = o.Total
+	default:
+		cursor.Time = o.CreatedAt
+	}
+	return cursor
+}
+
+// PlacedOrder is the result of placing an order.
+type PlacedOrder struct {
+	ID string `json:"id"`
+	// AccessToken lets the holder read and pay the order without logging in. Only its hash is stored.
+	AccessToken string `json:"-"`
+}
+
+// IdempotencyKeyTTL is how long an idempotency key refers to its order. Older keys are ignored and can be used again.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyKey links a client supplied key to the order created with it.
+type IdempotencyKey struct {
+	// Scope separates keys of different clients: the customer, or the email of a guest.
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+	// RequestHash identifies the payload of the request, so the key can not be reused for another order.
+	RequestHash string    `json:"request_hash"`
+	OrderID     string    `json:"order_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Weight returns the total weight of the items in grams.
+func (o *Order) Weight() int64 {
+	var weight int64
+	for _, item := range o.Items {
+		weight += int64(item.UnitWeight) * int64(item.Quantity)
+	}
+	return weight
+}
+
+// CalculateTotals fills line totals, subtotal, tax and total from the unit prices of the items,
+// the discount, the shipping cost and the tax lines.
+func (o *Order) CalculateTotals() {
+	o.Subtotal = 0
+	for i := range o.Items {
+		o.Items[i].LineTotal = o.Items[i].UnitPrice * int64(o.Items[i].Quantity)
+		o.Subtotal += o.Items[i].LineTotal
+	}
+	o.Tax = 0
+	o.Total = o.Subtotal - o.Discount + o.ShippingCost
+	for _, line := range o.TaxLines {
+		o.Tax += line.Amount
+		if !line.Inclusive {
+			o.Total += line.Amount
+		}
+	}
 }
 
 func (o *Order) ConvertToMessage() *common.Order {
@@ -44,16 +306,48 @@ func (o *Order) ConvertToMessage() *common.Order {
 		items = append(items, &common.OrderItem{
 			ProductId: item.ProductID,
 			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			LineTotal: item.LineTotal,
 		})
 	}
 
-	return &common.Order{
-		Id:            o.ID,
-		CustomerName:  o.CustomerName,
-		CustomerEmail: o.CustomerEmail,
-		Items:         items,
-		Status:        common.OrderStatus(o.Status),
-		CreatedAt:     timestamppb.New(o.CreatedAt),
-		UpdatedAt:     timestamppb.New(o.UpdatedAt),
+	taxLines := make([]*common.TaxLine, 0, len(o.TaxLines))
+	for _, line := range o.TaxLines {
+		taxLines = append(taxLines, line.ConvertToMessage())
+	}
+
+	history := make([]*common.OrderStatusChange, 0, len(o.History))
+	for _, change := range o.History {
+		history = append(history, change.ConvertToMessage())
+	}
+
+	message := &common.Order{
+		Id:                 o.ID,
+		CustomerId:         o.CustomerID,
+		CustomerName:       o.CustomerName,
+		CustomerEmail:      o.CustomerEmail,
+		Items:              items,
+		Status:             common.OrderStatus(o.Status),
+		Subtotal:           o.Subtotal,
+		CouponCode:         o.CouponCode,
+		Discount:           o.Discount,
+		TaxCountry:         o.TaxCountry,
+		TaxRegion:          o.TaxRegion,
+		TaxLines:           taxLines,
+		Tax:                o.Tax,
+		ShippingMethodId:   o.ShippingMethodID,
+		ShippingMethodName: o.ShippingMethodName,
+		ShippingCost:       o.ShippingCost,
+		Total:              o.Total,
+		History:            history,
+		CreatedAt:          timestamppb.New(o.CreatedAt),
+		UpdatedAt:          timestamppb.New(o.UpdatedAt),
+	}
+	if o.ShippingAddress != nil {
+		message.ShippingAddress = o.ShippingAddress.ConvertToMessage()
+	}
+	if o.BillingAddress != nil {
+		message.BillingAddress = o.BillingAddress.ConvertToMessage()
 	}
+	return message
 }
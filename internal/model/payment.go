@@ -0,0 +1,80 @@
+package model
+
+import (
+	"go_store/generated/proto/common"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"slices"
+	"time"
+)
+
+type PaymentStatus string
+
+// PaymentPendingTTL is how long a payment can stay pending. Older pending payments are marked failed
+// with PaymentExpiredReason, so that a crash, a timeout or a callback that never comes does not keep
+// the order from being paid.
+const PaymentPendingTTL = time.Hour
+
+// PaymentExpiredReason is the failure reason of pending payments that were not completed in time.
+const PaymentExpiredReason = "not completed by the payment provider in time"
+
+const (
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentVoided     PaymentStatus = "voided"
+	PaymentRefunded   PaymentStatus = "refunded"
+	PaymentFailed     PaymentStatus = "failed"
+)
+
+var paymentStatusMessages = map[PaymentStatus]common.PaymentStatus{
+	PaymentPending:    common.PaymentStatus_PAYMENT_STATUS_PENDING,
+	PaymentAuthorized: common.PaymentStatus_PAYMENT_STATUS_AUTHORIZED,
+	PaymentCaptured:   common.PaymentStatus_PAYMENT_STATUS_CAPTURED,
+	PaymentVoided:     common.PaymentStatus_PAYMENT_STATUS_VOIDED,
+	PaymentRefunded:   common.PaymentStatus_PAYMENT_STATUS_REFUNDED,
+	PaymentFailed:     common.PaymentStatus_PAYMENT_STATUS_FAILED,
+}
+
+// paymentStatusTransitions lists the statuses a payment can be moved to from each status.
+// A partial refund keeps the payment captured. VOIDED, REFUNDED and FAILED are final.
+var paymentStatusTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:    {PaymentAuthorized, PaymentCaptured, PaymentFailed},
+	PaymentAuthorized: {PaymentCaptured, PaymentVoided},
+	PaymentCaptured:   {PaymentRefunded},
+}
+
+// CanTransitionTo reports whether a payment with status s can be moved to next.
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	return slices.Contains(paymentStatusTransitions[s], next)
+}
+
+type Payment struct {
+	ID      string `json:"id"`
+	OrderID string `json:"order_id"`
+	// Provider is the name of the payment provider and Reference the ID of the payment there.
+	Provider       string        `json:"provider"`
+	Reference      string        `json:"reference"`
+	Status         PaymentStatus `json:"status"`
+	Amount         int64         `json:"amount"`
+	CapturedAmount int64         `json:"captured_amount"`
+	RefundedAmount int64         `json:"refunded_amount"`
+	FailureReason  string        `json:"failure_reason"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+func (p *Payment) ConvertToMessage() *common.Payment {
+	return &common.Payment{
+		Id:             p.ID,
+		OrderId:        p.OrderID,
+		Provider:       p.Provider,
+		Reference:      p.Reference,
+		Status:         paymentStatusMessages[p.Status],
+		Amount:         p.Amount,
+		CapturedAmount: p.CapturedAmount,
+		RefundedAmount: p.RefundedAmount,
+		FailureReason:  p.FailureReason,
+		CreatedAt:      timestamppb.New(p.CreatedAt),
+		UpdatedAt:      timestamppb.New(p.UpdatedAt),
+	}
+}
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"go_store/generated/proto/admin"
+	"go_store/generated/proto/common"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Address is a shipping or billing address. Country and Region are upper-case ISO 3166 codes.
+type Address struct {
+	FullName   string `json:"full_name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+}
+
+// AddressFromMessage converts an address from the API, normalizing the codes. It returns nil for nil.
+func AddressFromMessage(address *common.Address) *Address {
+	if address == nil {
+		return nil
+	}
+	return &Address{
+		FullName:   address.FullName,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     strings.ToUpper(address.Region),
+		PostalCode: address.PostalCode,
+		Country:    strings.ToUpper(address.Country),
+		Phone:      address.Phone,
+	}
+}
+
+// CheckComplete returns an error if the address can not be used to deliver an order.
+// field is the request field holding the address.
+func (a *Address) CheckComplete(field string) error {
+	switch {
+	case a.FullName == "":
+		return &InvalidArgumentError{Field: field + ".full_name", Description: "must not be empty"}
+	case a.Line1 == "":
+		return &InvalidArgumentError{Field: field + ".line1", Description: "must not be empty"}
+	case a.City == "":
+		return &InvalidArgumentError{Field: field + ".city", Description: "must not be empty"}
+	}
+	return nil
+}
+
+func (a *Address) ConvertToMessage() *common.Address {
+	return &common.Address{
+		Country:    a.Country,
+		Region:     a.Region,
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		PostalCode: a.PostalCode,
+		Phone:      a.Phone,
+	}
+}
+
+type ShippingRateType string
+
+const (
+	ShippingRateFlat       ShippingRateType = "flat"
+	ShippingRateWeight     ShippingRateType = "weight"
+	ShippingRateOrderValue ShippingRateType = "order_value"
+)
+
+var shippingRateTypeMessages = map[ShippingRateType]admin.ShippingRateType{
+	ShippingRateFlat:       admin.ShippingRateType_SHIPPING_RATE_TYPE_FLAT,
+	ShippingRateWeight:     admin.ShippingRateType_SHIPPING_RATE_TYPE_WEIGHT,
+	ShippingRateOrderValue: admin.ShippingRateType_SHIPPING_RATE_TYPE_ORDER_VALUE,
+}
+
+// ShippingRateTypeFromMessage converts a rate type from the API. It returns an empty type for unknown values.
+func ShippingRateTypeFromMessage(rateType admin.ShippingRateType) ShippingRateType {
+	for t, message := range shippingRateTypeMessages {
+		if message == rateType {
+			return t
+		}
+	}
+	return ""
+}
+
+// ShippingRate is a row of a rate table: Rate applies from MinValue grams or MinValue of the order value.
+type ShippingRate struct {
+	MinValue int64 `json:"min_value"`
+	Rate     int64 `json:"rate"`
+}
+
+type ShippingMethod struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	RateType    ShippingRateType `json:"rate_type"`
+	FlatRate    int64            `json:"flat_rate"`
+	// Rates are sorted by MinValue.
+	Rates []ShippingRate `json:"rates"`
+	// FreeOver is the order value from which shipping is free, zero means never.
+	FreeOver int64 `json:"free_over"`
+	// Countries the method ships to, empty means all.
+	Countries  []string   `json:"countries"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+// Cost returns the cost of shipping an order with the value and the weight in grams to the country.
+// The value of an order is its subtotal less the discount. It reports false if the method can not ship the order.
+func (m *ShippingMethod) Cost(country string, orderValue int64, weight int64) (int64, bool) {
+	if m.DisabledAt != nil || (len(m.Countries) > 0 && !slices.Contains(m.Countries, country)) {
+		return 0, false
+	}
+
+	var cost int64
+	switch m.RateType {
+	case ShippingRateFlat:
+		cost = m.FlatRate
+	case ShippingRateWeight, ShippingRateOrderValue:
+		value := weight
+		if m.RateType == ShippingRateOrderValue {
+			value = orderValue
+		}
+		found := false
+		for _, rate := range m.Rates {
+			if rate.MinValue > value {
+				break
+			}
+			cost, found = rate.Rate, true
+		}
+		if !found {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	if m.FreeOver > 0 && orderValue >= m.FreeOver {
+		return 0, true
+	}
+	return cost, true
+}
+
+func (m *ShippingMethod) ConvertToMessage() *admin.ShippingMethod {
+	rates := make([]*admin.ShippingRate, 0, len(m.Rates))
+	for _, rate := range m.Rates {
+		rates = append(rates, &admin.ShippingRate{MinValue: rate.MinValue, Rate: rate.Rate})
+	}
+	message := &admin.ShippingMethod{
+		Id:          m.ID,
+		Name:        m.Name,
+		Description: m.Description,
+		RateType:    shippingRateTypeMessages[m.RateType],
+		FlatRate:    m.FlatRate,
+		Rates:       rates,
+		FreeOver:    m.FreeOver,
+		Countries:   m.Countries,
+		CreatedAt:   timestamppb.New(m.CreatedAt),
+	}
+	if m.DisabledAt != nil {
+		message.DisabledAt = timestamppb.New(*m.DisabledAt)
+	}
+	return message
+}
+
+// ShippingQuote is the cost of shipping an order with a method.
+type ShippingQuote struct {
+	Method ShippingMethod
+	Cost   int64
+}
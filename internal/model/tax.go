@@ -0,0 +1,57 @@
+package model
+
+import (
+	"go_store/generated/proto/admin"
+	"go_store/generated/proto/common"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// TaxRule sets the tax of one tax class in a country or, if Region is set, in a region of it.
+type TaxRule struct {
+	ID       string `json:"id"`
+	Country  string `json:"country"`
+	Region   string `json:"region"`
+	TaxClass string `json:"tax_class"`
+	Name     string `json:"name"`
+	// Rate is in basis points: 2000 is 20%.
+	Rate int32 `json:"rate"`
+	// Inclusive taxes are part of the prices, exclusive ones are added to the total.
+	Inclusive bool      `json:"inclusive"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (r *TaxRule) ConvertToMessage() *admin.TaxRule {
+	return &admin.TaxRule{
+		Id:        r.ID,
+		Country:   r.Country,
+		Region:    r.Region,
+		TaxClass:  r.TaxClass,
+		Name:      r.Name,
+		Rate:      r.Rate,
+		Inclusive: r.Inclusive,
+		CreatedAt: timestamppb.New(r.CreatedAt),
+	}
+}
+
+// TaxLine is the tax charged for the items of one tax class of an order.
+type TaxLine struct {
+	TaxClass  string `json:"tax_class"`
+	Name      string `json:"name"`
+	Rate      int32  `json:"rate"`
+	Inclusive bool   `json:"inclusive"`
+	// TaxableAmount is the sum of the line totals of the items less their share of the discount.
+	TaxableAmount int64 `json:"taxable_amount"`
+	Amount        int64 `json:"amount"`
+}
+
+func (l *TaxLine) ConvertToMessage() *common.TaxLine {
+	return &common.TaxLine{
+		TaxClass:      l.TaxClass,
+		Name:          l.Name,
+		Rate:          l.Rate,
+		Inclusive:     l.Inclusive,
+		TaxableAmount: l.TaxableAmount,
+		Amount:        l.Amount,
+	}
+}
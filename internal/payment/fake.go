@@ -0,0 +1,201 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_store/internal/model"
+	"net/http"
+	"sync"
+)
+
+// Payment method tokens with special meaning for the fake provider. Any other token is authorized at once.
+const (
+	// FakeTokenDecline is declined.
+	FakeTokenDecline = "fake_decline"
+	// FakeTokenPending stays pending until Fake.Webhook confirms or declines it.
+	FakeTokenPending = "fake_pending"
+	// FakeTokenError makes the provider fail as if it were unreachable.
+	FakeTokenError = "fake_error"
+)
+
+// FakeSignatureHeader carries the hex HMAC-SHA256 of the callback body.
+const FakeSignatureHeader = "X-Fake-Signature"
+
+type fakePayment struct {
+	paymentID     string
+	status        model.PaymentStatus
+	failureReason string
+	amount        int64
+	captured      int64
+	refunded      int64
+}
+
+// fakeEvent is the body of fake callbacks.
+type fakeEvent struct {
+	PaymentID     string              `json:"payment_id"`
+	Reference     string              `json:"reference"`
+	Status        model.PaymentStatus `json:"status"`
+	FailureReason string              `json:"failure_reason"`
+}
+
+// Fake is an in-process provider for development and tests. Its results depend only on the requests:
+// the reference of a payment is derived from its ID and the outcome from the token. Payments are kept
+// in memory, so they can not be captured or refunded after a restart.
+type Fake struct {
+	mu       sync.Mutex
+	secret   []byte
+	payments map[string]*fakePayment
+}
+
+var _ PaymentProvider = (*Fake)(nil)
+
+// NewFake creates the fake provider. Callbacks are signed with secret; with an empty secret
+// ParseWebhook rejects all of them.
+func NewFake(secret string) *Fake {
+	return &Fake{secret: []byte(secret), payments: make(map[string]*fakePayment)}
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) Authorize(_ context.Context, request AuthorizeRequest) (*Authorization, error) {
+	if request.Token == FakeTokenError {
+		return nil, errors.New("fake provider is unavailable")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reference := "fake_" + request.PaymentID
+	p, ok := f.payments[reference]
+	if !ok {
+		p = &fakePayment{paymentID: request.PaymentID, status: model.PaymentAuthorized, amount: request.Amount}
+		switch request.Token {
+		case FakeTokenDecline:
+			p.status = model.PaymentFailed
+			p.failureReason = "card declined"
+		case FakeTokenPending:
+			p.status = model.PaymentPending
+		}
+		f.payments[reference] = p
+	}
+	return &Authorization{Reference: reference, Status: p.status, FailureReason: p.failureReason}, nil
+}
+
+func (f *Fake) Capture(_ context.Context, reference string, amount int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.payment(reference, model.PaymentAuthorized)
+	if err != nil {
+		return err
+	}
+	if amount <= 0 || amount > p.amount {
+		return fmt.Errorf("fake payment %s: can not capture %d of %d", reference, amount, p.amount)
+	}
+	p.status = model.PaymentCaptured
+	p.captured = amount
+	return nil
+}
+
+func (f *Fake) Void(_ context.Context, reference string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.payment(reference, model.PaymentAuthorized)
+	if err != nil {
+		return err
+	}
+	p.status = model.PaymentVoided
+	return nil
+}
+
+func (f *Fake) Refund(_ context.Context, reference string, amount int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.payment(reference, model.PaymentCaptured)
+	if err != nil {
+		return err
+	}
+	if amount <= 0 || p.refunded+amount > p.captured {
+		return fmt.Errorf("fake payment %s: can not refund %d, %d of %d already refunded", reference, amount, p.refunded, p.captured)
+	}
+	p.refunded += amount
+	if p.refunded == p.captured {
+		p.status = model.PaymentRefunded
+	}
+	return nil
+}
+
+// payment returns the payment with the reference if it has the status. f.mu must be held.
+func (f *Fake) payment(reference string, status model.PaymentStatus) (*fakePayment, error) {
+	p, ok := f.payments[reference]
+	if !ok {
+		return nil, fmt.Errorf("fake payment %s not found", reference)
+	}
+	if p.status != status {
+		return nil, fmt.Errorf("fake payment %s is %s, not %s", reference, p.status, status)
+	}
+	return p, nil
+}
+
+func (f *Fake) ParseWebhook(header http.Header, body []byte) (*Event, error) {
+	signature, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || len(f.secret) == 0 || !hmac.Equal(signature, f.sign(body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event fakeEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid fake event: %w", err)
+	}
+	return &Event{
+		PaymentID:     event.PaymentID,
+		Reference:     event.Reference,
+		Status:        event.Status,
+		FailureReason: event.FailureReason,
+	}, nil
+}
+
+// Webhook completes a pending payment with model.PaymentAuthorized or model.PaymentFailed
+// and returns the signed callback the provider sends about it.
+func (f *Fake) Webhook(reference string, status model.PaymentStatus) (http.Header, []byte, error) {
+	if status != model.PaymentAuthorized && status != model.PaymentFailed {
+		return nil, nil, fmt.Errorf("fake payment %s can not be completed with status %s", reference, status)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.payment(reference, model.PaymentPending)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.status = status
+	if status == model.PaymentFailed {
+		p.failureReason = "card declined"
+	}
+
+	event := fakeEvent{PaymentID: p.paymentID, Reference: reference, Status: status, FailureReason: p.failureReason}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(FakeSignatureHeader, hex.EncodeToString(f.sign(body)))
+	return header, body, nil
+}
+
+func (f *Fake) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
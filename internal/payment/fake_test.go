@@ -0,0 +1,279 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"go_store/internal/model"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testSecret = "webhook-secret"
+
+// authorize authorizes a payment of amount with the token and returns its reference.
+func authorize(t *testing.T, f *Fake, paymentID string, amount int64, token string) string {
+	t.Helper()
+	result, err := f.Authorize(context.Background(), AuthorizeRequest{PaymentID: paymentID, OrderID: "order", Amount: amount, Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Reference
+}
+
+func TestFakeAuthorize(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus model.PaymentStatus
+		wantReason string
+		wantErr    bool
+	}{
+		{"any other token is authorized", "tok_visa", model.PaymentAuthorized, "", false},
+		{"decline", FakeTokenDecline, model.PaymentFailed, "card declined", false},
+		{"pending", FakeTokenPending, model.PaymentPending, "", false},
+		{"error", FakeTokenError, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewFake(testSecret).Authorize(context.Background(),
+				AuthorizeRequest{PaymentID: "p1", OrderID: "order", Amount: 1000, Token: tt.token})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Authorize = %+v, want error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Authorization{Reference: "fake_p1", Status: tt.wantStatus, FailureReason: tt.wantReason}
+			if *result != want {
+				t.Errorf("Authorize = %+v, want %+v", *result, want)
+			}
+		})
+	}
+}
+
+func TestFakeAuthorizeRetry(t *testing.T) {
+	f := NewFake(testSecret)
+	if _, err := f.Authorize(context.Background(), AuthorizeRequest{PaymentID: "p1", Amount: 1000, Token: FakeTokenError}); err == nil {
+		t.Fatal("Authorize with the error token succeeded")
+	}
+	authorize(t, f, "p1", 1000, FakeTokenDecline)
+
+	// A retry with the same payment ID gets the outcome of the first accepted request.
+	result, err := f.Authorize(context.Background(), AuthorizeRequest{PaymentID: "p1", Amount: 1000, Token: "tok_visa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != model.PaymentFailed {
+		t.Errorf("status = %s, want %s", result.Status, model.PaymentFailed)
+	}
+}
+
+func TestFakeCapture(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		amount  int64
+		wantErr bool
+	}{
+		{"whole amount", "tok_visa", 1000, false},
+		{"part of the amount", "tok_visa", 400, false},
+		{"zero", "tok_visa", 0, true},
+		{"more than authorized", "tok_visa", 1001, true},
+		{"pending payment", FakeTokenPending, 1000, true},
+		{"declined payment", FakeTokenDecline, 1000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFake(testSecret)
+			reference := authorize(t, f, "p1", 1000, tt.token)
+			err := f.Capture(context.Background(), reference, tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Capture(%d) error = %v, want error %v", tt.amount, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("captured twice", func(t *testing.T) {
+		f := NewFake(testSecret)
+		reference := authorize(t, f, "p1", 1000, "tok_visa")
+		if err := f.Capture(context.Background(), reference, 1000); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Capture(context.Background(), reference, 1000); err == nil {
+			t.Error("second Capture succeeded")
+		}
+	})
+
+	t.Run("unknown payment", func(t *testing.T) {
+		if err := NewFake(testSecret).Capture(context.Background(), "fake_unknown", 1000); err == nil {
+			t.Error("Capture of an unknown payment succeeded")
+		}
+	})
+}
+
+func TestFakeVoid(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		capture bool
+		wantErr bool
+	}{
+		{"authorized", "tok_visa", false, false},
+		{"captured", "tok_visa", true, true},
+		{"pending", FakeTokenPending, false, true},
+		{"declined", FakeTokenDecline, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFake(testSecret)
+			reference := authorize(t, f, "p1", 1000, tt.token)
+			if tt.capture {
+				if err := f.Capture(context.Background(), reference, 1000); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := f.Void(context.Background(), reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Void error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFakeRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture int64
+		refunds []int64
+		wantErr bool
+	}{
+		{"whole captured amount", 600, []int64{600}, false},
+		{"in parts", 600, []int64{100, 200, 300}, false},
+		{"more than captured", 600, []int64{601}, true},
+		{"parts over the captured amount", 600, []int64{400, 201}, true},
+		{"after a full refund", 600, []int64{600, 1}, true},
+		{"zero", 600, []int64{0}, true},
+		{"not captured", 0, []int64{100}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFake(testSecret)
+			reference := authorize(t, f, "p1", 1000, "tok_visa")
+			if tt.capture > 0 {
+				if err := f.Capture(context.Background(), reference, tt.capture); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var err error
+			for i, amount := range tt.refunds {
+				err = f.Refund(context.Background(), reference, amount)
+				if err != nil && i < len(tt.refunds)-1 {
+					t.Fatalf("Refund(%d) = %v", amount, err)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("last Refund error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFakeParseWebhook(t *testing.T) {
+	f := NewFake(testSecret)
+	reference := authorize(t, f, "p1", 1000, FakeTokenPending)
+	header, body, err := f.Webhook(reference, model.PaymentAuthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamperedHeader := header.Clone()
+	tamperedHeader.Set(FakeSignatureHeader, strings.Repeat("0", len(header.Get(FakeSignatureHeader))))
+	notHexHeader := header.Clone()
+	notHexHeader.Set(FakeSignatureHeader, "not hex")
+
+	tests := []struct {
+		name    string
+		fake    *Fake
+		header  http.Header
+		body    []byte
+		wantErr error
+	}{
+		{"valid signature", f, header, body, nil},
+		{"body changed", f, header, append([]byte(" "), body...), ErrInvalidSignature},
+		{"signature changed", f, tamperedHeader, body, ErrInvalidSignature},
+		{"signature not hex", f, notHexHeader, body, ErrInvalidSignature},
+		{"no signature", f, http.Header{}, body, ErrInvalidSignature},
+		{"another secret", NewFake("another-secret"), header, body, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := tt.fake.ParseWebhook(tt.header, tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseWebhook error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			want := Event{PaymentID: "p1", Reference: reference, Status: model.PaymentAuthorized}
+			if *event != want {
+				t.Errorf("ParseWebhook = %+v, want %+v", *event, want)
+			}
+		})
+	}
+}
+
+func TestFakeParseWebhookEmptySecret(t *testing.T) {
+	// With an empty secret the callbacks it signs itself are rejected too.
+	f := NewFake("")
+	reference := authorize(t, f, "p1", 1000, FakeTokenPending)
+	header, body, err := f.Webhook(reference, model.PaymentAuthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.ParseWebhook(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ParseWebhook error = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestFakeWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		status     model.PaymentStatus
+		wantReason string
+		wantErr    bool
+	}{
+		{"authorized", FakeTokenPending, model.PaymentAuthorized, "", false},
+		{"failed", FakeTokenPending, model.PaymentFailed, "card declined", false},
+		{"captured is not a completion", FakeTokenPending, model.PaymentCaptured, "", true},
+		{"payment not pending", "tok_visa", model.PaymentAuthorized, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFake(testSecret)
+			reference := authorize(t, f, "p1", 1000, tt.token)
+			header, body, err := f.Webhook(reference, tt.status)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Webhook error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			event, err := f.ParseWebhook(header, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Status != tt.status || event.FailureReason != tt.wantReason {
+				t.Errorf("event = %+v, want status %s and reason %q", *event, tt.status, tt.wantReason)
+			}
+			if _, _, err = f.Webhook(reference, tt.status); err == nil {
+				t.Error("second Webhook for the payment succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package payment connects the store to payment providers.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_store/config"
+	"go_store/internal/model"
+	"net/http"
+)
+
+// ErrInvalidSignature is returned for callbacks that were not sent by the provider.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// AuthorizeRequest asks the provider to reserve the amount on the customer's payment method.
+type AuthorizeRequest struct {
+	// PaymentID is the ID of the payment in the store. Providers use it to deduplicate retried requests
+	// and send it back in callbacks.
+	PaymentID string
+	OrderID   string
+	Amount    int64
+	// Token identifies the payment method. It is issued by the provider to the storefront,
+	// so card details never reach the store.
+	Token string
+}
+
+// Authorization is the outcome of an authorization request.
+type Authorization struct {
+	Reference string
+	// Status is model.PaymentAuthorized, model.PaymentFailed for declined payments,
+	// or model.PaymentPending if the result will be sent later in a callback.
+	Status        model.PaymentStatus
+	FailureReason string
+}
+
+// Event is a change of a payment status reported by the provider in a callback.
+type Event struct {
+	PaymentID     string
+	Reference     string
+	Status        model.PaymentStatus
+	FailureReason string
+}
+
+// PaymentProvider moves money through an external payment service. Amounts are in the same minor units
+// as order prices. Implementations must be safe for concurrent use.
+type PaymentProvider interface {
+	// Name identifies the provider in payment records.
+	Name() string
+
+	Authorize(ctx context.Context, request AuthorizeRequest) (*Authorization, error)
+
+	// Capture charges the amount of an authorized payment and releases the rest of the authorization.
+	Capture(ctx context.Context, reference string, amount int64) error
+
+	// Void releases an authorized payment that was not captured.
+	Void(ctx context.Context, reference string) error
+
+	// Refund returns the amount of a captured payment. It can be called several times for partial refunds.
+	Refund(ctx context.Context, reference string, amount int64) error
+
+	// ParseWebhook checks that a callback was sent by the provider and returns the event it reports.
+	// It returns ErrInvalidSignature if the check fails.
+	ParseWebhook(header http.Header, body []byte) (*Event, error)
+}
+
+// New creates the provider selected by cfg.Provider.
+func New(cfg *config.Payment) (PaymentProvider, error) {
+	switch cfg.Provider {
+	case config.PaymentProviderFake:
+		return NewFake(cfg.WebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"go_store/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ AdminRepository = (*adminRepositoryImpl)(nil)
+
+type adminRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewAdminRepository(db *pgxpool.Pool) AdminRepository {
+	return &adminRepositoryImpl{db: db}
+}
+
+func (a *adminRepositoryImpl) Create(ctx context.Context, admin *model.AdminUser) (string, error) {
+	const query = `
+INSERT INTO admin_user (username, password_hash, roles)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+	var result string
+	err := a.db.QueryRow(ctx, query, admin.Username, admin.PasswordHash, rolesToStrings(admin.Roles)).
+		Scan(&result)
+	if err != nil {
+		return "", mapError(err, "admin user", "")
+	}
+	return result, nil
+}
+
+func (a *adminRepositoryImpl) CreateIfNone(ctx context.Context, admin *model.AdminUser) (bool, error) {
+	const query = `
+INSERT INTO admin_user (username, password_hash, roles)
+SELECT $1, $2, $3
+WHERE NOT EXISTS(SELECT 1 FROM admin_user)
+`
+	tag, err := a.db.Exec(ctx, query, admin.Username, admin.PasswordHash, rolesToStrings(admin.Roles))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (a *adminRepositoryImpl) GetByID(ctx context.Context, id string) (*model.AdminUser, error) {
+	const query = `
+SELECT id, username, password_hash, roles, created_at, disabled_at, COALESCE(totp_secret, ''), totp_enabled_at
+FROM admin_user
+WHERE id = $1
+`
+	return a.get(ctx, query, id)
+}
+
+func (a *adminRepositoryImpl) GetByUsername(ctx context.Context, username string) (*model.AdminUser, error) {
+	const query = `
+SELECT id, username, password_hash, roles, created_at, disabled_at, COALESCE(totp_secret, ''), totp_enabled_at
+FROM admin_user
+WHERE username = $1
+`
+	return a.get(ctx, query, username)
+}
+
+func (a *adminRepositoryImpl) get(ctx context.Context, query string, key string) (*model.AdminUser, error) {
+	var admin model.AdminUser
+	var roles []string
+	err := a.db.QueryRow(ctx, query, key).Scan(
+		&admin.ID, &admin.Username, &admin.PasswordHash, &roles, &admin.CreatedAt, &admin.DisabledAt,
+		&admin.TOTPSecret, &admin.TOTPEnabledAt,
+	)
+	if err != nil {
+		return nil, mapError(err, "admin user", key)
+	}
+	admin.Roles = stringsToRoles(roles)
+	return &admin, nil
+}
+
+func (a *adminRepositoryImpl) List(ctx context.Context, limit, offset int32) ([]model.AdminUser, error) {
+	const query = `
+SELECT id, username, roles, created_at, disabled_at, totp_enabled_at
+FROM admin_user
+ORDER BY username
+LIMIT $1 OFFSET $2
+`
+	rows, err := a.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var admins []model.AdminUser
+	for rows.Next() {
+		var admin model.AdminUser
+		var roles []string
+		err = rows.Scan(&admin.ID, &admin.Username, &roles, &admin.CreatedAt, &admin.DisabledAt, &admin.TOTPEnabledAt)
+		if err != nil {
+			return nil, err
+		}
+		admin.Roles = stringsToRoles(roles)
+		admins = append(admins, admin)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
+
+func (a *adminRepositoryImpl) Disable(ctx context.Context, id string) error {
+	const query = `
+UPDATE admin_user
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+`
+	tag, err := a.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "admin user", ID: id}
+	}
+	return nil
+}
+
+func (a *adminRepositoryImpl) UpdatePasswordHash(ctx context.Context, id string, passwordHash string) error {
+	const query = `
+UPDATE admin_user
+SET password_hash = $1
+WHERE id = $2
+`
+	tag, err := a.db.Exec(ctx, query, passwordHash, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "admin user", ID: id}
+	}
+	return nil
+}
+
+func (a *adminRepositoryImpl) SetTOTPSecret(ctx context.Context, id string, secret string) error {
+	const query = `
+UPDATE admin_user
+SET totp_secret = $1, totp_last_step = NULL
+WHERE id = $2 AND totp_enabled_at IS NULL
+`
+	tag, err := a.db.Exec(ctx, query, secret, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+func (a *adminRepositoryImpl) EnableTOTP(ctx context.Context, id string, step int64, recoveryCodeHashes []string) error {
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const enableQuery = `
+UPDATE admin_user
+SET totp_enabled_at = now(), totp_last_step = $1
+WHERE id = $2 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+`
+	tag, err := tx.Exec(ctx, enableQuery, step, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrTOTPNotEnrolled
+	}
+
+	if err = replaceRecoveryCodes(ctx, tx, id, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// replaceRecoveryCodes drops all recovery codes of the administrator and stores the new ones.
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, adminID string, codeHashes []string) error {
+	const deleteQuery = `
+DELETE FROM admin_recovery_code WHERE admin_id = $1
+`
+	if _, err := tx.Exec(ctx, deleteQuery, adminID); err != nil {
+		return err
+	}
+
+	const insertQuery = `
+INSERT INTO admin_recovery_code (admin_id, code_hash)
+SELECT $1, unnest($2::text[])
+`
+	_, err := tx.Exec(ctx, insertQuery, adminID, codeHashes)
+	return err
+}
+
+func (a *adminRepositoryImpl) DisableTOTP(ctx context.Context, id string) error {
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const disableQuery = `
+UPDATE admin_user
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL
+WHERE id = $1
+`
+	tag, err := tx.Exec(ctx, disableQuery, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "admin user", ID: id}
+	}
+
+	if err = replaceRecoveryCodes(ctx, tx, id, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (a *adminRepositoryImpl) UseTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	const query = `
+UPDATE admin_user
+SET totp_last_step = $1
+WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+`
+	tag, err := a.db.Exec(ctx, query, step, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (a *adminRepositoryImpl) UseRecoveryCode(ctx context.Context, id string, codeHash string) (bool, error) {
+	const query = `
+UPDATE admin_recovery_code
+SET used_at = now()
+WHERE admin_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+	tag, err := a.db.Exec(ctx, query, id, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func rolesToStrings(roles []model.AdminRole) []string {
+	result := make([]string, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, string(role))
+	}
+	return result
+}
+
+func stringsToRoles(roles []string) []model.AdminRole {
+	result := make([]model.AdminRole, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, model.AdminRole(role))
+	}
+	return result
+}
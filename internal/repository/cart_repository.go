@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"go_store/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ CartRepository = (*cartRepositoryImpl)(nil)
+
+type cartRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewCartRepository(db *pgxpool.Pool) CartRepository {
+	return &cartRepositoryImpl{db: db}
+}
+
+func (c *cartRepositoryImpl) Create(ctx context.Context, customerID string) (string, error) {
+	if customerID == "" {
+		const query = `
+INSERT INTO cart DEFAULT VALUES
+RETURNING id
+`
+		var result string
+		if err := c.db.QueryRow(ctx, query).Scan(&result); err != nil {
+			return "", err
+		}
+		return result, nil
+	}
+	return customerCart(ctx, c.db, customerID)
+}
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// customerCart returns the cart of the customer, creating it if needed.
+func customerCart(ctx context.Context, q queryRower, customerID string) (string, error) {
+	const query = `
+INSERT INTO cart (customer_id)
+VALUES ($1)
+ON CONFLICT (customer_id) DO UPDATE SET customer_id = EXCLUDED.customer_id
+RETURNING id
+`
+	var result string
+	if err := q.QueryRow(ctx, query, customerID).Scan(&result); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func (c *cartRepositoryImpl) GetByID(ctx context.Context, id string) (*model.Cart, error) {
+	const cartQuery = `
+SELECT id, COALESCE(customer_id::text, ''), created_at, updated_at
+FROM cart
+WHERE id = $1
+`
+	var cart model.Cart
+	err := c.db.QueryRow(ctx, cartQuery, id).Scan(&cart.ID, &cart.CustomerID, &cart.CreatedAt, &cart.UpdatedAt)
+	if err != nil {
+		return nil, mapError(err, "cart", id)
+	}
+
+	const itemsQuery = `
+SELECT ci.product_id, p.name, ci.quantity, p.price, p.archived_at IS NULL AND p.stock >= ci.quantity
+FROM cart_item ci
+JOIN product p ON p.id = ci.product_id
+WHERE ci.cart_id = $1
+ORDER BY ci.added_at, ci.product_id
+`
+	rows, err := c.db.Query(ctx, itemsQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cart.Items = []model.CartItem{}
+	for rows.Next() {
+		var item model.CartItem
+		if err = rows.Scan(&item.ProductID, &item.ProductName, &item.Quantity, &item.UnitPrice, &item.Available); err != nil {
+			return nil, err
+		}
+		cart.Items = append(cart.Items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cart.CalculateTotals()
+	return &cart, nil
+}
+
+func (c *cartRepositoryImpl) AddItem(ctx context.Context, cartID string, productID string, quantity int32) error {
+	const query = `
+INSERT INTO cart_item (cart_id, product_id, quantity)
+SELECT $1, id, $3 FROM product WHERE id = $2 AND archived_at IS NULL
+ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = cart_item.quantity + EXCLUDED.quantity
+`
+	missing := &model.InvalidReferenceError{Field: "product_id", Entity: "product", ID: productID}
+	return c.changeItem(ctx, cartID, missing, query, cartID, productID, quantity)
+}
+
+func (c *cartRepositoryImpl) SetItem(ctx context.Context, cartID string, productID string, quantity int32) error {
+	const query = `
+UPDATE cart_item
+SET quantity = $3
+WHERE cart_id = $1 AND product_id = $2
+`
+	missing := &model.NotFoundError{Entity: "cart item", ID: productID}
+	return c.changeItem(ctx, cartID, missing, query, cartID, productID, quantity)
+}
+
+func (c *cartRepositoryImpl) RemoveItem(ctx context.Context, cartID string, productID string) error {
+	const query = `
+DELETE FROM cart_item
+WHERE cart_id = $1 AND product_id = $2
+`
+	missing := &model.NotFoundError{Entity: "cart item", ID: productID}
+	return c.changeItem(ctx, cartID, missing, query, cartID, productID)
+}
+
+// changeItem runs a query changing one item of the cart and updates the cart modification time.
+// missing is returned if the query does not affect any rows.
+func (c *cartRepositoryImpl) changeItem(ctx context.Context, cartID string, missing error, query string, args ...any) error {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = touchCart(ctx, tx, cartID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return missing
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (c *cartRepositoryImpl) Merge(ctx context.Context, guestCartID string, customerID string) error {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const guestQuery = `
+SELECT EXISTS(SELECT 1 FROM cart WHERE id = $1 AND customer_id IS NULL)
+`
+	var isGuest bool
+	if err = tx.QueryRow(ctx, guestQuery, guestCartID).Scan(&isGuest); err != nil {
+		return err
+	}
+	if !isGuest {
+		return &model.NotFoundError{Entity: "cart", ID: guestCartID}
+	}
+
+	cartID, err := customerCart(ctx, tx, customerID)
+	if err != nil {
+		return err
+	}
+	if err = touchCart(ctx, tx, cartID); err != nil {
+		return err
+	}
+
+	const mergeQuery = `
+INSERT INTO cart_item (cart_id, product_id, quantity, added_at)
+SELECT $1, product_id, quantity, added_at
+FROM cart_item
+WHERE cart_id = $2
+ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = cart_item.quantity + EXCLUDED.quantity
+`
+	if _, err = tx.Exec(ctx, mergeQuery, cartID, guestCartID); err != nil {
+		return err
+	}
+
+	const deleteQuery = `
+DELETE FROM cart WHERE id = $1
+`
+	if _, err = tx.Exec(ctx, deleteQuery, guestCartID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// touchCart updates the modification time of the cart and locks it until the end of the transaction.
+func touchCart(ctx context.Context, tx pgx.Tx, cartID string) error {
+	const query = `
+UPDATE cart SET updated_at = now() WHERE id = $1
+`
+	tag, err := tx.Exec(ctx, query, cartID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "cart", ID: cartID}
+	}
+	return nil
+}
+
+// takeCartItems locks the cart and returns its items for an order placed in tx. The lock keeps the items
+// from changing until deleteCartItems removes them in the same transaction.
+func takeCartItems(ctx context.Context, tx pgx.Tx, cartID string) ([]model.OrderItem, error) {
+	if err := touchCart(ctx, tx, cartID); err != nil {
+		return nil, err
+	}
+
+	const query = `
+SELECT product_id, quantity
+FROM cart_item
+WHERE cart_id = $1
+ORDER BY added_at, product_id
+`
+	rows, err := tx.Query(ctx, query, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.OrderItem
+	for rows.Next() {
+		var item model.OrderItem
+		if err = rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, model.ErrCartEmpty
+	}
+	return items, nil
+}
+
+// deleteCartItems removes the items taken by takeCartItems from the cart.
+func deleteCartItems(ctx context.Context, tx pgx.Tx, cartID string, items []model.OrderItem) error {
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	const query = `
+DELETE FROM cart_item
+WHERE cart_id = $1 AND product_id = ANY($2::uuid[])
+`
+	_, err := tx.Exec(ctx, query, cartID, productIDs)
+	return err
+}
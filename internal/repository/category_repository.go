@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"go_store/internal/model"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ CategoryRepository = (*categoryRepositoryImpl)(nil)
+
+type categoryRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewCategoryRepository(db *pgxpool.Pool) CategoryRepository {
+	return &categoryRepositoryImpl{db: db}
+}
+
+func (c *categoryRepositoryImpl) Create(ctx context.Context, category *model.Category) (string, error) {
+	const query = `
+INSERT INTO category (parent_id, name, description)
+VALUES (NULLIF($1, '')::uuid, $2, $3)
+RETURNING id
+`
+	var result string
+	err := c.db.QueryRow(ctx, query, category.ParentID, category.Name, category.Description).
+		Scan(&result)
+	if err != nil {
+		return "", mapError(err, "category", "")
+	}
+	return result, nil
+}
+
+func (c *categoryRepositoryImpl) Update(ctx context.Context, category *model.Category, fields []string) (*model.Category, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	assignments := make([]string, 0, len(fields))
+	args := make([]any, 0, len(fields)+1)
+	for _, field := range fields {
+		switch field {
+		case model.CategoryFieldName:
+			args = append(args, category.Name)
+			assignments = append(assignments, fmt.Sprintf("name = $%d", len(args)))
+		case model.CategoryFieldDescription:
+			args = append(args, category.Description)
+			assignments = append(assignments, fmt.Sprintf("description = $%d", len(args)))
+		case model.CategoryFieldParentID:
+			if err = checkCategoryParent(ctx, tx, category.ID, category.ParentID); err != nil {
+				return nil, err
+			}
+			args = append(args, category.ParentID)
+			assignments = append(assignments, fmt.Sprintf("parent_id = NULLIF($%d, '')::uuid", len(args)))
+		default:
+			return nil, fmt.Errorf("unknown category field %q", field)
+		}
+	}
+	args = append(args, category.ID)
+
+	query := fmt.Sprintf(`
+UPDATE category
+SET %s
+WHERE id = $%d
+RETURNING id, COALESCE(parent_id::text, ''), name, description
+`, strings.Join(assignments, ", "), len(args))
+
+	var result model.Category
+	err = tx.QueryRow(ctx, query, args...).
+		Scan(&result.ID, &result.ParentID, &result.Name, &result.Description)
+	if err != nil {
+		return nil, mapError(err, "category", category.ID)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// checkCategoryParent makes sure that moving the category under parentID does not create a cycle.
+// The table is locked so that two concurrent moves can not create a cycle together.
+func checkCategoryParent(ctx context.Context, tx pgx.Tx, id, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE category IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	const query = `
+WITH RECURSIVE subtree AS (
+    SELECT id FROM category WHERE id = $1
+    UNION
+    SELECT c.id FROM category c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT EXISTS(SELECT 1 FROM subtree WHERE id = $2)
+`
+	var cycle bool
+	if err := tx.QueryRow(ctx, query, id, parentID).Scan(&cycle); err != nil {
+		return err
+	}
+	if cycle {
+		return model.ErrCategoryCycle
+	}
+	return nil
+}
+
+func (c *categoryRepositoryImpl) Delete(ctx context.Context, id string) error {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const childrenQuery = `
+SELECT EXISTS(SELECT 1 FROM category WHERE parent_id = $1)
+`
+	var hasChildren bool
+	if err = tx.QueryRow(ctx, childrenQuery, id).Scan(&hasChildren); err != nil {
+		return err
+	}
+	if hasChildren {
+		return model.ErrCategoryHasChildren
+	}
+
+	const query = `
+DELETE FROM category WHERE id = $1
+`
+	tag, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "category", ID: id}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (c *categoryRepositoryImpl) List(ctx context.Context) ([]model.Category, error) {
+	const query = `
+SELECT id, COALESCE(parent_id::text, ''), name, description
+FROM category
+ORDER BY name
+`
+	rows, err := c.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []model.Category
+	for rows.Next() {
+		var category model.Category
+		if err = rows.Scan(&category.ID, &category.ParentID, &category.Name, &category.Description); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (c *categoryRepositoryImpl) SetProductCategories(ctx context.Context, productID string, categoryIDs []string) error {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const deleteQuery = `
+DELETE FROM product_category WHERE product_id = $1
+`
+	if _, err = tx.Exec(ctx, deleteQuery, productID); err != nil {
+		return err
+	}
+
+	const insertQuery = `
+INSERT INTO product_category (product_id, category_id)
+SELECT $1, unnest($2::uuid[])
+`
+	if _, err = tx.Exec(ctx, insertQuery, productID, categoryIDs); err != nil {
+		return mapError(err, "product_category", productID)
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go_store/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ CouponRepository = (*couponRepositoryImpl)(nil)
+
+type couponRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewCouponRepository(db *pgxpool.Pool) CouponRepository {
+	return &couponRepositoryImpl{db: db}
+}
+
+// couponColumns are read by scanCoupon. uses does not count cancelled orders.
+const couponColumns = `
+c.id, c.code, c.type, c.value, COALESCE(c.free_product_id::text, ''), c.min_order_value, c.starts_at, c.ends_at,
+c.max_uses, c.max_uses_per_customer, c.created_at, c.disabled_at,
+(SELECT count(*) FROM orders o WHERE o.coupon_id = c.id AND o.status <> $1)
+`
+
+func scanCoupon(row pgx.Row) (*model.Coupon, error) {
+	var coupon model.Coupon
+	err := row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&coupon.Type,
+		&coupon.Value,
+		&coupon.FreeProductID,
+		&coupon.MinOrderValue,
+		&coupon.StartsAt,
+		&coupon.EndsAt,
+		&coupon.MaxUses,
+		&coupon.MaxUsesPerCustomer,
+		&coupon.CreatedAt,
+		&coupon.DisabledAt,
+		&coupon.Uses,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (c *couponRepositoryImpl) Create(ctx context.Context, coupon *model.Coupon) (string, error) {
+	const query = `
+INSERT INTO coupon (code, type, value, free_product_id, min_order_value, starts_at, ends_at, max_uses, max_uses_per_customer)
+VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9)
+RETURNING id
+`
+	var id string
+	err := c.db.QueryRow(ctx, query,
+		coupon.Code,
+		coupon.Type,
+		coupon.Value,
+		coupon.FreeProductID,
+		coupon.MinOrderValue,
+		coupon.StartsAt,
+		coupon.EndsAt,
+		coupon.MaxUses,
+		coupon.MaxUsesPerCustomer,
+	).Scan(&id)
+	if err != nil {
+		return "", mapError(err, "coupon", coupon.Code)
+	}
+	return id, nil
+}
+
+func (c *couponRepositoryImpl) List(ctx context.Context, limit, offset int32) ([]model.Coupon, error) {
+	const query = `
+SELECT ` + couponColumns + `
+FROM coupon c
+ORDER BY c.created_at DESC, c.id
+LIMIT $2 OFFSET $3
+`
+	rows, err := c.db.Query(ctx, query, model.CANCELLED, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := []model.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, *coupon)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (c *couponRepositoryImpl) Disable(ctx context.Context, id string) error {
+	const query = `
+UPDATE coupon
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+`
+	tag, err := c.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "coupon", ID: id}
+	}
+	return nil
+}
+
+// applyCoupon applies the coupon with order.CouponCode to the order. The coupon is locked until the end
+// of the transaction, so concurrent orders can not exceed its usage limits.
+func applyCoupon(ctx context.Context, tx pgx.Tx, order *model.Order) error {
+	const couponQuery = `
+SELECT ` + couponColumns + `
+FROM coupon c
+WHERE lower(c.code) = lower($2)
+FOR UPDATE
+`
+	coupon, err := scanCoupon(tx.QueryRow(ctx, couponQuery, model.CANCELLED, order.CouponCode))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &model.InvalidReferenceError{Field: "coupon_code", Entity: "coupon", ID: order.CouponCode}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Uses are counted by a statement started after the lock is acquired, so they include the orders of
+	// transactions that held the lock before; coupon.Uses was read by the locking statement and may miss them.
+	const usageQuery = `
+SELECT count(*),
+       count(*) FILTER (WHERE customer_id = NULLIF($3, '')::uuid OR lower(customer_email) = lower($4))
+FROM orders
+WHERE coupon_id = $1
+  AND status <> $2
+`
+	var usage model.CouponUsage
+	err = tx.QueryRow(ctx, usageQuery, coupon.ID, model.CANCELLED, order.CustomerID, order.CustomerEmail).
+		Scan(&usage.Total, &usage.Customer)
+	if err != nil {
+		return err
+	}
+
+	return coupon.Apply(order, usage, time.Now())
+}
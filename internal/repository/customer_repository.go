@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_store/internal/model"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ CustomerRepository = (*customerRepositoryImpl)(nil)
+
+type customerRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewCustomerRepository(db *pgxpool.Pool) CustomerRepository {
+	return &customerRepositoryImpl{db: db}
+}
+
+func (c *customerRepositoryImpl) Create(ctx context.Context, customer *model.Customer) (string, error) {
+	const query = `
+INSERT INTO customer (email, password_hash, name)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+	var result string
+	err := c.db.QueryRow(ctx, query, customer.Email, customer.PasswordHash, customer.Name).
+		Scan(&result)
+	if err != nil {
+		return "", mapError(err, "customer", "")
+	}
+	return result, nil
+}
+
+func (c *customerRepositoryImpl) GetByID(ctx context.Context, id string) (*model.Customer, error) {
+	const query = `
+SELECT id, email, password_hash, name, created_at, updated_at, email_verified_at, sessions_revoked_at
+FROM customer
+WHERE id = $1
+`
+	return c.get(ctx, query, id)
+}
+
+func (c *customerRepositoryImpl) GetByEmail(ctx context.Context, email string) (*model.Customer, error) {
+	const query = `
+SELECT id, email, password_hash, name, created_at, updated_at, email_verified_at, sessions_revoked_at
+FROM customer
+WHERE lower(email) = lower($1)
+`
+	return c.get(ctx, query, email)
+}
+
+func (c *customerRepositoryImpl) get(ctx context.Context, query string, key string) (*model.Customer, error) {
+	var customer model.Customer
+	err := c.db.QueryRow(ctx, query, key).Scan(
+		&customer.ID, &customer.Email, &customer.PasswordHash, &customer.Name, &customer.CreatedAt, &customer.UpdatedAt,
+		&customer.EmailVerifiedAt, &customer.SessionsRevokedAt,
+	)
+	if err != nil {
+		return nil, mapError(err, "customer", key)
+	}
+	return &customer, nil
+}
+
+func (c *customerRepositoryImpl) Update(ctx context.Context, customer *model.Customer, fields []string) (*model.Customer, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+
+	assignments := make([]string, 0, len(fields)+3)
+	args := make([]any, 0, len(fields)+1)
+	for _, field := range fields {
+		var value any
+		switch field {
+		case model.CustomerFieldEmail:
+			value = customer.Email
+			// A new address has to be verified again, and the tokens issued for the old one are revoked.
+			assignments = append(assignments, fmt.Sprintf(
+				"email_verified_at = CASE WHEN lower(email) = lower($%d) THEN email_verified_at END", len(args)+1,
+			), fmt.Sprintf(
+				"sessions_revoked_at = CASE WHEN lower(email) = lower($%d) THEN sessions_revoked_at ELSE now() END", len(args)+1,
+			))
+		case model.CustomerFieldName:
+			value = customer.Name
+		default:
+			return nil, fmt.Errorf("unknown customer field %q", field)
+		}
+		args = append(args, value)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", field, len(args)))
+	}
+	assignments = append(assignments, "updated_at = now()")
+	args = append(args, customer.ID)
+
+	query := fmt.Sprintf(`
+UPDATE customer
+SET %s
+WHERE id = $%d
+RETURNING id, email, password_hash, name, created_at, updated_at, email_verified_at, sessions_revoked_at
+`, strings.Join(assignments, ", "), len(args))
+
+	var result model.Customer
+	err := c.db.QueryRow(ctx, query, args...).Scan(
+		&result.ID, &result.Email, &result.PasswordHash, &result.Name, &result.CreatedAt, &result.UpdatedAt,
+		&result.EmailVerifiedAt, &result.SessionsRevokedAt,
+	)
+	if err != nil {
+		return nil, mapError(err, "customer", customer.ID)
+	}
+	return &result, nil
+}
+
+func (c *customerRepositoryImpl) CreateEmailToken(ctx context.Context, token *model.EmailToken) error {
+	const deleteQuery = `
+DELETE FROM customer_email_token
+WHERE customer_id = $1 AND expires_at < now()
+`
+	if _, err := c.db.Exec(ctx, deleteQuery, token.CustomerID); err != nil {
+		return err
+	}
+
+	const query = `
+INSERT INTO customer_email_token (id, customer_id, purpose, expires_at)
+VALUES ($1, $2, $3, $4)
+`
+	_, err := c.db.Exec(ctx, query, token.ID, token.CustomerID, token.Purpose, token.ExpiresAt)
+	return mapError(err, "email token", token.ID)
+}
+
+func (c *customerRepositoryImpl) VerifyEmail(ctx context.Context, tokenID string, email string) (bool, error) {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	customerID, ok, err := useEmailToken(ctx, tx, tokenID, model.EmailTokenVerifyEmail)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	const query = `
+UPDATE customer
+SET email_verified_at = COALESCE(email_verified_at, now())
+WHERE id = $1 AND lower(email) = lower($2)
+`
+	tag, err := tx.Exec(ctx, query, customerID, email)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *customerRepositoryImpl) ResetPassword(ctx context.Context, tokenID string, passwordHash string) (bool, error) {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	customerID, ok, err := useEmailToken(ctx, tx, tokenID, model.EmailTokenResetPassword)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	const query = `
+UPDATE customer
+SET password_hash = $1, sessions_revoked_at = now(), updated_at = now()
+WHERE id = $2
+`
+	if _, err = tx.Exec(ctx, query, passwordHash, customerID); err != nil {
+		return false, err
+	}
+
+	const revokeQuery = `
+UPDATE customer_email_token
+SET used_at = now()
+WHERE customer_id = $1 AND purpose = $2 AND used_at IS NULL
+`
+	if _, err = tx.Exec(ctx, revokeQuery, customerID, model.EmailTokenResetPassword); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// useEmailToken marks the token as used and returns its customer. It returns false if the token
+// does not exist, has another purpose, has expired or was already used.
+func useEmailToken(ctx context.Context, tx pgx.Tx, id string, purpose model.EmailTokenPurpose) (string, bool, error) {
+	const query = `
+UPDATE customer_email_token
+SET used_at = now()
+WHERE id = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+RETURNING customer_id
+`
+	var customerID string
+	err := tx.QueryRow(ctx, query, id, purpose).Scan(&customerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return customerID, true, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"errors"
+	"go_store/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+type reference struct {
+	field  string
+	entity string
+}
+
+// foreignKeys maps foreign key constraints to the request field holding the reference.
+var foreignKeys = map[string]reference{
+	"order_item_product_id_fkey":        {field: "items.product_id", entity: "product"},
+	"category_parent_id_fkey":           {field: "parent_id", entity: "category"},
+	"product_category_product_id_fkey":  {field: "product_id", entity: "product"},
+	"product_category_category_id_fkey": {field: "category_ids", entity: "category"},
+	"coupon_free_product_id_fkey":       {field: "free_product_id", entity: "product"},
+	"payment_order_id_fkey":             {field: "order_id", entity: "order"},
+}
+
+// mapError converts database errors into domain errors. entity and id describe the row
+// the query was looking for. Errors without a domain meaning are returned unchanged.
+func mapError(err error, entity, id string) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &model.NotFoundError{Entity: entity, ID: id}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case foreignKeyViolation:
+			if ref, ok := foreignKeys[pgErr.ConstraintName]; ok {
+				return &model.InvalidReferenceError{Field: ref.field, Entity: ref.entity}
+			}
+		case uniqueViolation:
+			return &model.AlreadyExistsError{Entity: entity}
+		}
+	}
+	return err
+}
@@ -3,26 +3,269 @@ package repository
 import (
 	"context"
 	"go_store/internal/model"
+	"time"
 )
 
 type ProductRepository interface {
 	Create(ctx context.Context, product *model.Product) (string, error)
 
+	// GetByID returns a product that is not archived.
 	GetByID(ctx context.Context, id string) (*model.Product, error)
 
+	// GetByIDs returns the products that exist and are not archived, in no particular order.
+	GetByIDs(ctx context.Context, ids []string) ([]model.Product, error)
+
+	// Update changes only the given fields (see model.ProductField*) and returns the updated product.
+	Update(ctx context.Context, product *model.Product, fields []string) (*model.Product, error)
+
+	Archive(ctx context.Context, id string) error
+
+	Restore(ctx context.Context, id string) error
+
+	SetStock(ctx context.Context, id string, stock int32) (int32, error)
+
+	AdjustStock(ctx context.Context, id string, delta int32) (int32, error)
+
+	// List returns active products ordered by name. If after is set, the list starts right after it.
+	List(ctx context.Context, filter model.ProductFilter, after *model.ProductCursor, limit, offset int32) ([]model.Product, error)
+
+	ListArchived(ctx context.Context, limit, offset int32) ([]model.Product, error)
+
+	// Search returns active products matching the full-text query, most relevant first.
+	Search(ctx context.Context, query string, limit, offset int32) ([]model.ProductSearchResult, error)
+}
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *model.Category) (string, error)
+
+	// Update changes only the given fields (see model.CategoryField*) and returns the updated category.
+	Update(ctx context.Context, category *model.Category, fields []string) (*model.Category, error)
+
 	Delete(ctx context.Context, id string) error
 
-	List(ctx context.Context, limit, offset int32) ([]model.Product, error)
+	List(ctx context.Context) ([]model.Category, error)
+
+	// SetProductCategories replaces the categories the product is assigned to.
+	SetProductCategories(ctx context.Context, productID string, categoryIDs []string) error
 }
 
 type OrderRepository interface {
-	Create(ctx context.Context, order *model.Order) (string, error)
+	// Create places the order. If order.CartID is set, the items are taken from the cart, which is locked
+	// and emptied in the same transaction; model.ErrCartEmpty is returned for an empty cart.
+	// If order.CouponCode is set, the coupon is applied or
+	// model.CouponNotApplicableError is returned. finalize, if not nil, is called in the transaction once
+	// prices and the discount are known, to add shipping and taxes. If key is set, it is stored with the ID of the order
+	// in the same transaction; model.AlreadyExistsError is returned if the key is already used in its scope
+	// and has not expired.
+	// accessTokenHash is stored as the first access token of the order.
+	Create(ctx context.Context, order *model.Order, accessTokenHash string, key *model.IdempotencyKey,
+		finalize func(order *model.Order) error) (string, error)
+
+	// GetIdempotencyKey returns the key of the scope, or model.NotFoundError if there is none or it has expired.
+	GetIdempotencyKey(ctx context.Context, scope string, key string) (*model.IdempotencyKey, error)
+
+	// DeleteExpiredIdempotencyKeys removes keys older than model.IdempotencyKeyTTL.
+	DeleteExpiredIdempotencyKeys(ctx context.Context) error
+
+	// AddAccessToken stores another access token of the order.
+	AddAccessToken(ctx context.Context, orderID string, tokenHash string) error
+
+	// HasAccessToken reports whether the token with the hash gives access to the order.
+	HasAccessToken(ctx context.Context, orderID string, tokenHash string) (bool, error)
 
 	GetByID(ctx context.Context, id string) (*model.Order, error)
 
-	UpdateStatus(ctx context.Context, order *model.Order) error
+	// UpdateStatus moves the order from change.OldStatus to change.NewStatus and records the change
+	// in the order history. Items of a cancelled order are returned to stock. If checkPayments is set,
+	// it is called with the payments of the locked order and its error aborts the change.
+	UpdateStatus(ctx context.Context, id string, change *model.OrderStatusChange,
+		checkPayments func(payments []model.Payment) error) error
+
+	GetHistory(ctx context.Context, id string) ([]model.OrderStatusChange, error)
+
+	Delete(ctx context.Context, id string) error
+
+	// List returns orders matching the filter in the given order. If after is set, the list starts right after it.
+	List(ctx context.Context, filter model.OrderFilter, sorting model.OrderSort, after *model.OrderCursor, limit, offset int32) ([]model.Order, error)
+}
+
+type CouponRepository interface {
+	Create(ctx context.Context, coupon *model.Coupon) (string, error)
+
+	// List returns coupons from the newest, with the number of uses of each.
+	List(ctx context.Context, limit, offset int32) ([]model.Coupon, error)
+
+	Disable(ctx context.Context, id string) error
+}
+
+type TaxRepository interface {
+	CreateRule(ctx context.Context, rule *model.TaxRule) (string, error)
+
+	// ListRules returns the rules of the country, or of all countries if it is empty.
+	ListRules(ctx context.Context, country string) ([]model.TaxRule, error)
+
+	DeleteRule(ctx context.Context, id string) error
+}
+
+type ShippingRepository interface {
+	CreateMethod(ctx context.Context, method *model.ShippingMethod) (string, error)
+
+	// GetMethod returns the method with its rate table, even if it is disabled.
+	GetMethod(ctx context.Context, id string) (*model.ShippingMethod, error)
+
+	ListMethods(ctx context.Context, includeDisabled bool) ([]model.ShippingMethod, error)
+
+	DisableMethod(ctx context.Context, id string) error
+}
+
+type PaymentRepository interface {
+	// Create fails with model.ErrOrderNotPayable if the order is not PENDING, and with model.ErrOrderAlreadyPaid
+	// if the order has a payment that neither failed nor was voided.
+	Create(ctx context.Context, payment *model.Payment) (string, error)
+
+	GetByID(ctx context.Context, id string) (*model.Payment, error)
+
+	// ListByOrder returns the payments of the order, newest first.
+	ListByOrder(ctx context.Context, orderID string) ([]model.Payment, error)
+
+	// Update saves the payment if its status and amounts still match previous,
+	// otherwise it fails with model.ErrPaymentChanged.
+	Update(ctx context.Context, payment *model.Payment, previous *model.Payment) error
+
+	// ExpirePending marks payments pending for longer than model.PaymentPendingTTL failed
+	// with model.PaymentExpiredReason and returns their number.
+	ExpirePending(ctx context.Context) (int64, error)
+}
+
+type AdminRepository interface {
+	Create(ctx context.Context, admin *model.AdminUser) (string, error)
 
+	// CreateIfNone creates the administrator only if there are no administrators yet.
+	// It reports whether the administrator was created.
+	CreateIfNone(ctx context.Context, admin *model.AdminUser) (bool, error)
+
+	// GetByID and GetByUsername return the administrator including the password hash, even if disabled.
+	GetByID(ctx context.Context, id string) (*model.AdminUser, error)
+
+	GetByUsername(ctx context.Context, username string) (*model.AdminUser, error)
+
+	List(ctx context.Context, limit, offset int32) ([]model.AdminUser, error)
+
+	Disable(ctx context.Context, id string) error
+
+	UpdatePasswordHash(ctx context.Context, id string, passwordHash string) error
+
+	// SetTOTPSecret starts two-factor enrollment. It fails with model.ErrTOTPAlreadyEnabled if it is confirmed.
+	SetTOTPSecret(ctx context.Context, id string, secret string) error
+
+	// EnableTOTP confirms the enrollment with the period of the first accepted code and replaces recovery codes.
+	EnableTOTP(ctx context.Context, id string, step int64, recoveryCodeHashes []string) error
+
+	// DisableTOTP removes the secret and recovery codes.
+	DisableTOTP(ctx context.Context, id string) error
+
+	// UseTOTPStep records the period of an accepted code. It returns false if a code of this or a later
+	// period was already used.
+	UseTOTPStep(ctx context.Context, id string, step int64) (bool, error)
+
+	// UseRecoveryCode marks the recovery code as used. It returns false if there is no such unused code.
+	UseRecoveryCode(ctx context.Context, id string, codeHash string) (bool, error)
+}
+
+type CartRepository interface {
+	// Create creates a guest cart if customerID is empty. A customer has one cart, which is created
+	// on the first call and returned afterwards.
+	Create(ctx context.Context, customerID string) (string, error)
+
+	// GetByID returns the cart with items priced at the current product prices.
+	GetByID(ctx context.Context, id string) (*model.Cart, error)
+
+	// AddItem adds the quantity to the item. The product must not be archived.
+	AddItem(ctx context.Context, cartID string, productID string, quantity int32) error
+
+	SetItem(ctx context.Context, cartID string, productID string, quantity int32) error
+
+	RemoveItem(ctx context.Context, cartID string, productID string) error
+
+	// Merge moves items of the guest cart to the cart of the customer, adding up quantities,
+	// and deletes the guest cart.
+	Merge(ctx context.Context, guestCartID string, customerID string) error
+}
+
+type CustomerRepository interface {
+	// Create fails with model.AlreadyExistsError if the email is taken, ignoring case.
+	Create(ctx context.Context, customer *model.Customer) (string, error)
+
+	// GetByID and GetByEmail return the customer including the password hash.
+	GetByID(ctx context.Context, id string) (*model.Customer, error)
+
+	GetByEmail(ctx context.Context, email string) (*model.Customer, error)
+
+	// Update changes only the given fields (see model.CustomerField*) and returns the updated customer.
+	// Changing the email resets its verification.
+	Update(ctx context.Context, customer *model.Customer, fields []string) (*model.Customer, error)
+
+	// CreateEmailToken stores a token sent by email, so that it can be used once.
+	CreateEmailToken(ctx context.Context, token *model.EmailToken) error
+
+	// VerifyEmail uses the verification token and marks the email as verified if it is still the email
+	// of the customer. It returns false if the token can not be used.
+	VerifyEmail(ctx context.Context, tokenID string, email string) (bool, error)
+
+	// ResetPassword uses the reset token, sets the password and voids other reset tokens of the customer.
+	// It returns false if the token can not be used.
+	ResetPassword(ctx context.Context, tokenID string, passwordHash string) (bool, error)
+}
+
+type LoginChallengeRepository interface {
+	Create(ctx context.Context, challenge *model.LoginChallenge) error
+
+	// Attempt counts an attempt to complete the challenge and returns it. Expired challenges
+	// and challenges with more than maxAttempts attempts are not returned.
+	Attempt(ctx context.Context, tokenHash string, maxAttempts int) (*model.LoginChallenge, error)
+
+	// Delete removes the challenge together with all expired challenges.
 	Delete(ctx context.Context, id string) error
+}
+
+type RefreshTokenRepository interface {
+	// Create stores a refresh token issued on login. It starts a new token family.
+	Create(ctx context.Context, token *model.RefreshToken) error
+
+	// Rotate revokes the token with the given hash and stores next in its place, in the same family.
+	// next.AdminID is set from the revoked token. Reusing a revoked token revokes the whole family.
+	Rotate(ctx context.Context, tokenHash string, next *model.RefreshToken) error
+
+	// RevokeFamily revokes the token with the given hash and all tokens of its family,
+	// if the token belongs to the administrator.
+	RevokeFamily(ctx context.Context, tokenHash string, username string) error
+}
+
+type RevocationRepository interface {
+	// RevokeToken adds an access token to the denylist.
+	RevokeToken(ctx context.Context, token *model.RevokedToken) error
+
+	// RevokeSessions revokes all access and refresh tokens issued to the administrator so far.
+	RevokeSessions(ctx context.Context, adminID string) (*model.SessionRevocation, error)
+
+	// ListRevokedTokens returns revoked access tokens that have not expired yet.
+	ListRevokedTokens(ctx context.Context) ([]model.RevokedToken, error)
+
+	// ListSessionRevocations returns session revocations made after since.
+	ListSessionRevocations(ctx context.Context, since time.Time) ([]model.SessionRevocation, error)
+
+	// DeleteExpired removes revoked access tokens that have expired anyway.
+	DeleteExpired(ctx context.Context) error
+}
+
+type LoginAttemptRepository interface {
+	// LockedUntil returns the latest lockout end among the keys, or zero time if none was locked.
+	LockedUntil(ctx context.Context, keys []string) (time.Time, error)
+
+	// RecordFailure counts a failed login for the key and locks it according to the policy.
+	// Failures before resetBefore are forgotten. It returns the lockout end, or zero time if not locked.
+	RecordFailure(ctx context.Context, key string, policy model.LockoutPolicy, resetBefore time.Time) (time.Time, error)
 
-	List(ctx context.Context, limit, offset int32) ([]model.Order, error)
+	// Reset forgets failures and lockouts of the keys.
+	Reset(ctx context.Context, keys []string) error
 }
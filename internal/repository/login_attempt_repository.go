@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"go_store/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ LoginAttemptRepository = (*loginAttemptRepositoryImpl)(nil)
+
+type loginAttemptRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(db *pgxpool.Pool) LoginAttemptRepository {
+	return &loginAttemptRepositoryImpl{db: db}
+}
+
+func (l *loginAttemptRepositoryImpl) LockedUntil(ctx context.Context, keys []string) (time.Time, error) {
+	const query = `
+SELECT max(locked_until)
+FROM login_attempt
+WHERE key = ANY($1)
+`
+	var result *time.Time
+	if err := l.db.QueryRow(ctx, query, keys).Scan(&result); err != nil {
+		return time.Time{}, err
+	}
+	if result == nil {
+		return time.Time{}, nil
+	}
+	return *result, nil
+}
+
+func (l *loginAttemptRepositoryImpl) RecordFailure(ctx context.Context, key string, policy model.LockoutPolicy, resetBefore time.Time) (time.Time, error) {
+	tx, err := l.db.Begin(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const failureQuery = `
+INSERT INTO login_attempt (key, failures, last_failure_at)
+VALUES ($1, 1, now())
+ON CONFLICT (key) DO UPDATE
+    SET failures        = CASE
+                              WHEN login_attempt.last_failure_at < $2 THEN 1
+                              ELSE login_attempt.failures + 1 END,
+        last_failure_at = now()
+RETURNING failures, last_failure_at
+`
+	var failures int
+	var failedAt time.Time
+	if err = tx.QueryRow(ctx, failureQuery, key, resetBefore).Scan(&failures, &failedAt); err != nil {
+		return time.Time{}, err
+	}
+
+	var lockedUntil time.Time
+	if lockout := policy.LockoutAfter(failures); lockout > 0 {
+		lockedUntil = failedAt.Add(lockout)
+		const lockQuery = `
+UPDATE login_attempt
+SET locked_until = $1
+WHERE key = $2
+`
+		if _, err = tx.Exec(ctx, lockQuery, lockedUntil, key); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return time.Time{}, err
+	}
+	return lockedUntil, nil
+}
+
+func (l *loginAttemptRepositoryImpl) Reset(ctx context.Context, keys []string) error {
+	const query = `
+DELETE FROM login_attempt WHERE key = ANY($1)
+`
+	_, err := l.db.Exec(ctx, query, keys)
+	return err
+}
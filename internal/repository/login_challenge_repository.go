@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"go_store/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ LoginChallengeRepository = (*loginChallengeRepositoryImpl)(nil)
+
+type loginChallengeRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginChallengeRepository(db *pgxpool.Pool) LoginChallengeRepository {
+	return &loginChallengeRepositoryImpl{db: db}
+}
+
+func (l *loginChallengeRepositoryImpl) Create(ctx context.Context, challenge *model.LoginChallenge) error {
+	const query = `
+INSERT INTO admin_login_challenge (admin_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+	return l.db.QueryRow(ctx, query, challenge.AdminID, challenge.TokenHash, challenge.ExpiresAt).
+		Scan(&challenge.ID)
+}
+
+func (l *loginChallengeRepositoryImpl) Attempt(ctx context.Context, tokenHash string, maxAttempts int) (*model.LoginChallenge, error) {
+	const query = `
+UPDATE admin_login_challenge
+SET attempts = attempts + 1
+WHERE token_hash = $1
+  AND expires_at > now()
+  AND attempts < $2
+RETURNING id, admin_id, token_hash, expires_at
+`
+	var challenge model.LoginChallenge
+	err := l.db.QueryRow(ctx, query, tokenHash, maxAttempts).
+		Scan(&challenge.ID, &challenge.AdminID, &challenge.TokenHash, &challenge.ExpiresAt)
+	if err != nil {
+		return nil, mapError(err, "login challenge", "")
+	}
+	return &challenge, nil
+}
+
+func (l *loginChallengeRepositoryImpl) Delete(ctx context.Context, id string) error {
+	const query = `
+DELETE FROM admin_login_challenge WHERE id = $1 OR expires_at <= now()
+`
+	_, err := l.db.Exec(ctx, query, id)
+	return err
+}
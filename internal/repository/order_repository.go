@@ -2,8 +2,14 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go_store/internal/model"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,7 +23,8 @@ func NewOrderRepository(db *pgxpool.Pool) OrderRepository {
 	return &orderRepositoryImpl{db: db}
 }
 
-func (o *orderRepositoryImpl) Create(ctx context.Context, order *model.Order) (string, error) {
+func (o *orderRepositoryImpl) Create(ctx context.Context, order *model.Order, accessTokenHash string, key *model.IdempotencyKey,
+	finalize func(order *model.Order) error) (string, error) {
 	tx, err := o.db.Begin(ctx)
 	if err != nil {
 		return "", err
@@ -26,36 +33,189 @@ func (o *orderRepositoryImpl) Create(ctx context.Context, order *model.Order) (s
 		_ = tx.Rollback(ctx)
 	}()
 
+	if order.CartID != "" {
+		if order.Items, err = takeCartItems(ctx, tx, order.CartID); err != nil {
+			return "", err
+		}
+	}
+
+	products, err := reserveStock(ctx, tx, order.Items)
+	if err != nil {
+		return "", err
+	}
+	for i := range order.Items {
+		product := products[order.Items[i].ProductID]
+		order.Items[i].UnitPrice = product.price
+		order.Items[i].TaxClass = product.taxClass
+		order.Items[i].UnitWeight = product.weight
+	}
+	order.CalculateTotals()
+	if order.CouponCode != "" {
+		if err = applyCoupon(ctx, tx, order); err != nil {
+			return "", err
+		}
+	}
+	if finalize != nil {
+		if err = finalize(order); err != nil {
+			return "", err
+		}
+	}
+
 	const orderInsert = `
-INSERT INTO orders (customer_name, customer_email, status)	
-VALUES ($1, $2, $3)
+INSERT INTO orders (customer_id, customer_name, customer_email, status, subtotal, coupon_id, coupon_code, discount,
+                    shipping_method_id, shipping_method_name, shipping_cost, tax_country, tax_region, tax, total)
+VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7, $8, NULLIF($9, '')::uuid, $10, $11, $12, $13, $14, $15)
 RETURNING id
 `
 	var createdID string
 
-	err = tx.QueryRow(ctx, orderInsert, order.CustomerName, order.CustomerEmail, order.Status).
+	err = tx.QueryRow(ctx, orderInsert, order.CustomerID, order.CustomerName, order.CustomerEmail, order.Status, order.Subtotal,
+		order.CouponID, order.CouponCode, order.Discount, order.ShippingMethodID, order.ShippingMethodName, order.ShippingCost,
+		order.TaxCountry, order.TaxRegion, order.Tax, order.Total).
 		Scan(&createdID)
 	if err != nil {
 		return "", err
 	}
 
+	const historyInsert = `
+INSERT INTO order_status_history (order_id, old_status, new_status, changed_by)
+VALUES ($1, $2, $3, '')
+`
+	if _, err = tx.Exec(ctx, historyInsert, createdID, model.UNSPECIFIED, order.Status); err != nil {
+		return "", err
+	}
+
 	const itemInsert = `
-INSERT INTO order_item (order_id, product_id, quantity)
-VALUES ($1, $2, $3)
+INSERT INTO order_item (order_id, product_id, quantity, unit_price, line_total, tax_class, unit_weight)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 `
 	for _, item := range order.Items {
-		_, err = tx.Exec(ctx, itemInsert, createdID, item.ProductID, item.Quantity)
+		_, err = tx.Exec(ctx, itemInsert, createdID, item.ProductID, item.Quantity, item.UnitPrice, item.LineTotal, item.TaxClass, item.UnitWeight)
 		if err != nil {
+			return "", mapError(err, "order item", item.ProductID)
+		}
+	}
+	if order.CartID != "" {
+		if err = deleteCartItems(ctx, tx, order.CartID, order.Items); err != nil {
 			return "", err
 		}
 	}
 
+	const taxLineInsert = `
+INSERT INTO order_tax_line (order_id, tax_class, name, rate, inclusive, taxable_amount, amount)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+	for _, line := range order.TaxLines {
+		_, err = tx.Exec(ctx, taxLineInsert, createdID, line.TaxClass, line.Name, line.Rate, line.Inclusive, line.TaxableAmount, line.Amount)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	const addressInsert = `
+INSERT INTO order_address (order_id, type, full_name, line1, line2, city, region, postal_code, country, phone)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+`
+	addresses := []struct {
+		addressType string
+		address     *model.Address
+	}{
+		{addressShipping, order.ShippingAddress},
+		{addressBilling, order.BillingAddress},
+	}
+	for _, a := range addresses {
+		if a.address == nil {
+			continue
+		}
+		_, err = tx.Exec(ctx, addressInsert, createdID, a.addressType, a.address.FullName, a.address.Line1, a.address.Line2,
+			a.address.City, a.address.Region, a.address.PostalCode, a.address.Country, a.address.Phone)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if _, err = tx.Exec(ctx, accessTokenInsert, accessTokenHash, createdID); err != nil {
+		return "", err
+	}
+
+	if key != nil {
+		// An expired key is taken over by the new order.
+		const keyInsert = `
+INSERT INTO order_idempotency_key (scope, key, request_hash, order_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (scope, key) DO UPDATE
+    SET request_hash = EXCLUDED.request_hash,
+        order_id     = EXCLUDED.order_id,
+        created_at   = now()
+    WHERE order_idempotency_key.created_at <= $5
+`
+		tag, err := tx.Exec(ctx, keyInsert, key.Scope, key.Key, key.RequestHash, createdID, idempotencyKeyExpiry())
+		if err != nil {
+			return "", err
+		}
+		if tag.RowsAffected() == 0 {
+			return "", &model.AlreadyExistsError{Entity: "idempotency key"}
+		}
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return "", err
 	}
 	return createdID, nil
 }
 
+func (o *orderRepositoryImpl) GetIdempotencyKey(ctx context.Context, scope string, key string) (*model.IdempotencyKey, error) {
+	const query = `
+SELECT scope, key, request_hash, order_id, created_at
+FROM order_idempotency_key
+WHERE scope = $1
+  AND key = $2
+  AND created_at > $3
+`
+	var result model.IdempotencyKey
+	err := o.db.QueryRow(ctx, query, scope, key, idempotencyKeyExpiry()).
+		Scan(&result.Scope, &result.Key, &result.RequestHash, &result.OrderID, &result.CreatedAt)
+	if err != nil {
+		return nil, mapError(err, "idempotency key", key)
+	}
+	return &result, nil
+}
+
+func (o *orderRepositoryImpl) DeleteExpiredIdempotencyKeys(ctx context.Context) error {
+	const query = `
+DELETE FROM order_idempotency_key
+WHERE created_at <= $1
+`
+	_, err := o.db.Exec(ctx, query, idempotencyKeyExpiry())
+	return err
+}
+
+// idempotencyKeyExpiry returns the creation time at or before which idempotency keys are expired.
+func idempotencyKeyExpiry() time.Time {
+	return time.Now().Add(-model.IdempotencyKeyTTL)
+}
+
+const accessTokenInsert = `
+INSERT INTO order_access_token (token_hash, order_id)
+VALUES ($1, $2)
+`
+
+func (o *orderRepositoryImpl) AddAccessToken(ctx context.Context, orderID string, tokenHash string) error {
+	_, err := o.db.Exec(ctx, accessTokenInsert, tokenHash, orderID)
+	return err
+}
+
+func (o *orderRepositoryImpl) HasAccessToken(ctx context.Context, orderID string, tokenHash string) (bool, error) {
+	const query = `
+SELECT EXISTS(SELECT 1 FROM order_access_token WHERE token_hash = $1 AND order_id = $2)
+`
+	var exists bool
+	if err := o.db.QueryRow(ctx, query, tokenHash, orderID).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
 func (o *orderRepositoryImpl) GetByID(ctx context.Context, id string) (*model.Order, error) {
 	tx, err := o.db.Begin(ctx)
 	if err != nil {
@@ -66,20 +226,26 @@ func (o *orderRepositoryImpl) GetByID(ctx context.Context, id string) (*model.Or
 	}()
 
 	const orderQuery = `
-SELECT customer_name, customer_email, status, created_at, updated_at
+SELECT COALESCE(customer_id::text, ''), customer_name, customer_email, status, subtotal,
+       COALESCE(coupon_id::text, ''), coupon_code, discount,
+       COALESCE(shipping_method_id::text, ''), shipping_method_name, shipping_cost,
+       tax_country, tax_region, tax, total, created_at, updated_at
 FROM orders 
 WHERE id = $1
 `
 	var order model.Order
 	order.ID = id
 	err = tx.QueryRow(ctx, orderQuery, id).
-		Scan(&order.CustomerName, &order.CustomerEmail, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+		Scan(&order.CustomerID, &order.CustomerName, &order.CustomerEmail, &order.Status, &order.Subtotal,
+			&order.CouponID, &order.CouponCode, &order.Discount,
+			&order.ShippingMethodID, &order.ShippingMethodName, &order.ShippingCost,
+			&order.TaxCountry, &order.TaxRegion, &order.Tax, &order.Total, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err, "order", id)
 	}
 
 	const itemsQuery = `
-SELECT product_id, quantity
+SELECT product_id, quantity, unit_price, line_total, tax_class, unit_weight
 FROM order_item WHERE order_id = $1
 `
 	rows, err := tx.Query(ctx, itemsQuery, order.ID)
@@ -90,7 +256,7 @@ FROM order_item WHERE order_id = $1
 
 	for rows.Next() {
 		var item model.OrderItem
-		if err = rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+		if err = rows.Scan(&item.ProductID, &item.Quantity, &item.UnitPrice, &item.LineTotal, &item.TaxClass, &item.UnitWeight); err != nil {
 			return nil, err
 		}
 		order.Items = append(order.Items, item)
@@ -100,6 +266,20 @@ FROM order_item WHERE order_id = $1
 		return nil, err
 	}
 
+	order.TaxLines, err = queryTaxLines(ctx, tx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = loadAddresses(ctx, tx, []*model.Order{&order}); err != nil {
+		return nil, err
+	}
+
+	order.History, err = queryHistory(ctx, tx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return nil, err
 	}
@@ -107,15 +287,93 @@ FROM order_item WHERE order_id = $1
 	return &order, nil
 }
 
-func (o *orderRepositoryImpl) UpdateStatus(ctx context.Context, order *model.Order) error {
+func (o *orderRepositoryImpl) UpdateStatus(ctx context.Context, id string, change *model.OrderStatusChange,
+	checkPayments func(payments []model.Payment) error) error {
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Payments are created under the same lock, so they can not change the outcome of checkPayments
+	// before the status is updated.
+	const lockQuery = `
+SELECT status
+FROM orders
+WHERE id = $1
+FOR UPDATE
+`
+	var status model.OrderStatus
+	if err = tx.QueryRow(ctx, lockQuery, id).Scan(&status); err != nil {
+		return mapError(err, "order", id)
+	}
+	if status != change.OldStatus {
+		return model.ErrOrderStatusChanged
+	}
+
+	if checkPayments != nil {
+		payments, err := queryPayments(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err = checkPayments(payments); err != nil {
+			return err
+		}
+	}
+
 	const query = `
 UPDATE orders
-SET status = $1 
+SET status = $1
 WHERE id = $2
 `
-	_, err := o.db.Exec(ctx, query, order.Status, order.ID)
+	if _, err = tx.Exec(ctx, query, change.NewStatus, id); err != nil {
+		return err
+	}
 
-	return err
+	const historyInsert = `
+INSERT INTO order_status_history (order_id, old_status, new_status, changed_by, reason)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING changed_at
+`
+	err = tx.QueryRow(ctx, historyInsert, id, change.OldStatus, change.NewStatus, change.ChangedBy, change.Reason).
+		Scan(&change.ChangedAt)
+	if err != nil {
+		return err
+	}
+
+	if change.NewStatus == model.CANCELLED {
+		const restockQuery = `
+UPDATE product p
+SET stock = p.stock + oi.quantity
+FROM (SELECT product_id, SUM(quantity) AS quantity
+      FROM order_item
+      WHERE order_id = $1
+      GROUP BY product_id) oi
+WHERE p.id = oi.product_id
+`
+		if _, err = tx.Exec(ctx, restockQuery, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (o *orderRepositoryImpl) GetHistory(ctx context.Context, id string) ([]model.OrderStatusChange, error) {
+	const existsQuery = `
+SELECT EXISTS(SELECT 1 FROM orders WHERE id = $1)
+`
+	var exists bool
+	if err := o.db.QueryRow(ctx, existsQuery, id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &model.NotFoundError{Entity: "order", ID: id}
+	}
+
+	return queryHistory(ctx, o.db, id)
 }
 
 func (o *orderRepositoryImpl) Delete(ctx context.Context, id string) error {
@@ -126,36 +384,79 @@ DELETE FROM orders WHERE id = $1
 	return err
 }
 
-func (o *orderRepositoryImpl) List(ctx context.Context, limit, offset int32) ([]model.Order, error) {
+func (o *orderRepositoryImpl) List(ctx context.Context, filter model.OrderFilter, sorting model.OrderSort, after *model.OrderCursor, limit, offset int32) ([]model.Order, error) {
 	tx, err := o.db.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
-	query := `
-SELECT id, customer_name, customer_email, status, created_at, updated_at
+	var args queryArgs
+	conditions := orderFilterConditions(filter, &args)
+
+	column, direction, comparison := "created_at", "DESC", "<"
+	switch sorting.Field {
+	case model.OrderSortByUpdatedAt:
+		column = "updated_at"
+	case model.OrderSortByTotal:
+		column = "total"
+	}
+	if sorting.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+
+	if after != nil {
+		var value any = after.Time
+		if sorting.Field == model.OrderSortByTotal {
+			value = after.Total
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)", column, comparison, args.add(value), args.add(after.ID)))
+	}
+
+	where := "TRUE"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, "\n  AND ")
+	}
+
+	query := fmt.Sprintf(`
+SELECT id, COALESCE(customer_id::text, ''), customer_name, customer_email, status, subtotal,
+       COALESCE(coupon_id::text, ''), coupon_code, discount,
+       COALESCE(shipping_method_id::text, ''), shipping_method_name, shipping_cost,
+       tax_country, tax_region, tax, total, created_at, updated_at
 FROM orders
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
-`
+WHERE %s
+ORDER BY %s %s, id %s
+LIMIT %s OFFSET %s
+`, where, column, direction, direction, args.add(limit), args.add(offset))
 
-	rows, err := tx.Query(ctx, query, limit, offset)
+	rows, err := tx.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var orders []model.Order
-	orderMap := make(map[string]*model.Order)
+	orderIndex := make(map[string]int)
 
 	for rows.Next() {
 		var order model.Order
 		err = rows.Scan(
 			&order.ID,
+			&order.CustomerID,
 			&order.CustomerName,
 			&order.CustomerEmail,
 			&order.Status,
+			&order.Subtotal,
+			&order.CouponID,
+			&order.CouponCode,
+			&order.Discount,
+			&order.ShippingMethodID,
+			&order.ShippingMethodName,
+			&order.ShippingCost,
+			&order.TaxCountry,
+			&order.TaxRegion,
+			&order.Tax,
+			&order.Total,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 		)
@@ -163,22 +464,22 @@ LIMIT $1 OFFSET $2
 			return nil, err
 		}
 		order.Items = []model.OrderItem{}
+		orderIndex[order.ID] = len(orders)
 		orders = append(orders, order)
-		orderMap[order.ID] = &orders[len(orders)-1]
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	if len(orderMap) > 0 {
+	if len(orders) > 0 {
 		itemQuery := `
-SELECT order_id, product_id, quantity
+SELECT order_id, product_id, quantity, unit_price, line_total, tax_class, unit_weight
 FROM order_item
 WHERE order_id = ANY($1)
 `
-		orderIDs := make([]string, 0, len(orderMap))
-		for id := range orderMap {
-			orderIDs = append(orderIDs, id)
+		orderIDs := make([]string, 0, len(orders))
+		for _, order := range orders {
+			orderIDs = append(orderIDs, order.ID)
 		}
 
 		itemRows, err := tx.Query(ctx, itemQuery, orderIDs)
@@ -190,17 +491,52 @@ WHERE order_id = ANY($1)
 		for itemRows.Next() {
 			var item model.OrderItem
 			var orderID string
-			err = itemRows.Scan(&orderID, &item.ProductID, &item.Quantity)
+			err = itemRows.Scan(&orderID, &item.ProductID, &item.Quantity, &item.UnitPrice, &item.LineTotal, &item.TaxClass, &item.UnitWeight)
 			if err != nil {
 				return nil, err
 			}
-			if order, ok := orderMap[orderID]; ok {
-				order.Items = append(order.Items, item)
+			if i, ok := orderIndex[orderID]; ok {
+				orders[i].Items = append(orders[i].Items, item)
 			}
 		}
 		if err = itemRows.Err(); err != nil {
 			return nil, err
 		}
+
+		const taxLineQuery = `
+SELECT order_id, tax_class, name, rate, inclusive, taxable_amount, amount
+FROM order_tax_line
+WHERE order_id = ANY($1)
+ORDER BY tax_class
+`
+		taxRows, err := tx.Query(ctx, taxLineQuery, orderIDs)
+		if err != nil {
+			return nil, err
+		}
+		defer taxRows.Close()
+
+		for taxRows.Next() {
+			var line model.TaxLine
+			var orderID string
+			err = taxRows.Scan(&orderID, &line.TaxClass, &line.Name, &line.Rate, &line.Inclusive, &line.TaxableAmount, &line.Amount)
+			if err != nil {
+				return nil, err
+			}
+			if i, ok := orderIndex[orderID]; ok {
+				orders[i].TaxLines = append(orders[i].TaxLines, line)
+			}
+		}
+		if err = taxRows.Err(); err != nil {
+			return nil, err
+		}
+
+		ordersToLoad := make([]*model.Order, 0, len(orders))
+		for i := range orders {
+			ordersToLoad = append(ordersToLoad, &orders[i])
+		}
+		if err = loadAddresses(ctx, tx, ordersToLoad); err != nil {
+			return nil, err
+		}
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -208,3 +544,213 @@ WHERE order_id = ANY($1)
 	}
 	return orders, nil
 }
+
+// reservedProduct is the current price, tax class and weight of an ordered product.
+type reservedProduct struct {
+	price    int64
+	taxClass string
+	weight   int32
+}
+
+// reserveStock decrements the stock of every ordered product and returns their current prices, tax classes and weights.
+// Products are locked in a stable order to avoid deadlocks between concurrent orders;
+// all shortages are reported at once.
+func reserveStock(ctx context.Context, tx pgx.Tx, items []model.OrderItem) (map[string]reservedProduct, error) {
+	// Summed in int64, so that repeated lines of a product can not wrap around the stock check.
+	requested := make(map[string]int64, len(items))
+	for _, item := range items {
+		requested[item.ProductID] += int64(item.Quantity)
+	}
+
+	productIDs := make([]string, 0, len(requested))
+	for id := range requested {
+		productIDs = append(productIDs, id)
+	}
+	sort.Strings(productIDs)
+
+	const selectQuery = `
+SELECT stock, price, tax_class, weight FROM product WHERE id = $1 AND archived_at IS NULL FOR UPDATE
+`
+	const updateQuery = `
+UPDATE product
+SET stock = stock - $1
+WHERE id = $2
+`
+	products := make(map[string]reservedProduct, len(productIDs))
+	var outOfStock []model.OutOfStockItem
+	for _, id := range productIDs {
+		var stock int32
+		var product reservedProduct
+		err := tx.QueryRow(ctx, selectQuery, id).Scan(&stock, &product.price, &product.taxClass, &product.weight)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &model.InvalidReferenceError{Field: "items.product_id", Entity: "product", ID: id}
+		}
+		if err != nil {
+			return nil, err
+		}
+		products[id] = product
+		if requested[id] > int64(stock) {
+			outOfStock = append(outOfStock, model.OutOfStockItem{
+				ProductID: id,
+				Requested: requested[id],
+				Available: stock,
+			})
+			continue
+		}
+		if _, err := tx.Exec(ctx, updateQuery, requested[id], id); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(outOfStock) > 0 {
+		return nil, &model.OutOfStockError{Items: outOfStock}
+	}
+	return products, nil
+}
+
+// orderFilterConditions returns SQL conditions for the filter, adding their arguments to args.
+func orderFilterConditions(filter model.OrderFilter, args *queryArgs) []string {
+	var conditions []string
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]int32, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, int32(status))
+		}
+		conditions = append(conditions, fmt.Sprintf("status = ANY(%s)", args.add(statuses)))
+	}
+	if filter.CustomerID != "" {
+		conditions = append(conditions, fmt.Sprintf("customer_id = %s", args.add(filter.CustomerID)))
+	}
+	if filter.CustomerEmail != "" {
+		conditions = append(conditions, fmt.Sprintf("lower(customer_email) = lower(%s)", args.add(filter.CustomerEmail)))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at >= %s", args.add(filter.CreatedAfter)))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at < %s", args.add(filter.CreatedBefore)))
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("updated_at >= %s", args.add(filter.UpdatedAfter)))
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("updated_at < %s", args.add(filter.UpdatedBefore)))
+	}
+	if filter.ProductID != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS(SELECT 1 FROM order_item oi WHERE oi.order_id = orders.id AND oi.product_id = %s)",
+			args.add(filter.ProductID),
+		))
+	}
+
+	return conditions
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func queryHistory(ctx context.Context, q querier, orderID string) ([]model.OrderStatusChange, error) {
+	const query = `
+SELECT old_status, new_status, changed_by, reason, changed_at
+FROM order_status_history
+WHERE order_id = $1
+ORDER BY changed_at
+`
+	rows, err := q.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []model.OrderStatusChange{}
+	for rows.Next() {
+		var change model.OrderStatusChange
+		err = rows.Scan(&change.OldStatus, &change.NewStatus, &change.ChangedBy, &change.Reason, &change.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func queryTaxLines(ctx context.Context, q querier, orderID string) ([]model.TaxLine, error) {
+	const query = `
+SELECT tax_class, name, rate, inclusive, taxable_amount, amount
+FROM order_tax_line
+WHERE order_id = $1
+ORDER BY tax_class
+`
+	rows, err := q.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []model.TaxLine{}
+	for rows.Next() {
+		var line model.TaxLine
+		err = rows.Scan(&line.TaxClass, &line.Name, &line.Rate, &line.Inclusive, &line.TaxableAmount, &line.Amount)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// Types of order addresses.
+const (
+	addressShipping = "shipping"
+	addressBilling  = "billing"
+)
+
+// loadAddresses fills the shipping and billing addresses of the given orders.
+func loadAddresses(ctx context.Context, q querier, orders []*model.Order) error {
+	orderIDs := make([]string, 0, len(orders))
+	orderByID := make(map[string]*model.Order, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+		orderByID[order.ID] = order
+	}
+
+	const query = `
+SELECT order_id, type, full_name, line1, line2, city, region, postal_code, country, phone
+FROM order_address
+WHERE order_id = ANY($1)
+`
+	rows, err := q.Query(ctx, query, orderIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address model.Address
+		var orderID, addressType string
+		err = rows.Scan(&orderID, &addressType, &address.FullName, &address.Line1, &address.Line2,
+			&address.City, &address.Region, &address.PostalCode, &address.Country, &address.Phone)
+		if err != nil {
+			return err
+		}
+		order, ok := orderByID[orderID]
+		if !ok {
+			continue
+		}
+		switch addressType {
+		case addressShipping:
+			order.ShippingAddress = &address
+		case addressBilling:
+			order.BillingAddress = &address
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go_store/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ PaymentRepository = (*paymentRepositoryImpl)(nil)
+
+type paymentRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentRepository(db *pgxpool.Pool) PaymentRepository {
+	return &paymentRepositoryImpl{db: db}
+}
+
+const paymentColumns = `
+id, order_id, provider, reference, status, amount, captured_amount, refunded_amount, failure_reason, created_at, updated_at
+`
+
+func scanPayment(row pgx.Row) (*model.Payment, error) {
+	var payment model.Payment
+	err := row.Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Provider,
+		&payment.Reference,
+		&payment.Status,
+		&payment.Amount,
+		&payment.CapturedAmount,
+		&payment.RefundedAmount,
+		&payment.FailureReason,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (p *paymentRepositoryImpl) Create(ctx context.Context, payment *model.Payment) (string, error) {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// The lock keeps the status of the order from changing until the payment is recorded,
+	// see orderRepositoryImpl.UpdateStatus.
+	const lockQuery = `
+SELECT status
+FROM orders
+WHERE id = $1
+FOR UPDATE
+`
+	var status model.OrderStatus
+	if err = tx.QueryRow(ctx, lockQuery, payment.OrderID).Scan(&status); err != nil {
+		return "", mapError(err, "order", payment.OrderID)
+	}
+	if status != model.PENDING {
+		return "", model.ErrOrderNotPayable
+	}
+
+	const query = `
+INSERT INTO payment (order_id, provider, reference, status, amount, failure_reason)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id
+`
+	var id string
+	err = tx.QueryRow(ctx, query, payment.OrderID, payment.Provider, payment.Reference, payment.Status,
+		payment.Amount, payment.FailureReason).Scan(&id)
+	if err != nil {
+		err = mapError(err, "payment", "")
+		// The only unique index checked on insert allows one active payment per order.
+		var exists *model.AlreadyExistsError
+		if errors.As(err, &exists) {
+			return "", model.ErrOrderAlreadyPaid
+		}
+		return "", err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (p *paymentRepositoryImpl) GetByID(ctx context.Context, id string) (*model.Payment, error) {
+	const query = `
+SELECT ` + paymentColumns + `
+FROM payment
+WHERE id = $1
+`
+	payment, err := scanPayment(p.db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, mapError(err, "payment", id)
+	}
+	return payment, nil
+}
+
+func (p *paymentRepositoryImpl) ListByOrder(ctx context.Context, orderID string) ([]model.Payment, error) {
+	return queryPayments(ctx, p.db, orderID)
+}
+
+func queryPayments(ctx context.Context, q querier, orderID string) ([]model.Payment, error) {
+	const query = `
+SELECT ` + paymentColumns + `
+FROM payment
+WHERE order_id = $1
+ORDER BY created_at DESC, id
+`
+	rows, err := q.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []model.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (p *paymentRepositoryImpl) Update(ctx context.Context, payment *model.Payment, previous *model.Payment) error {
+	const query = `
+UPDATE payment
+SET reference       = $2,
+    status          = $3,
+    captured_amount = $4,
+    refunded_amount = $5,
+    failure_reason  = $6,
+    updated_at      = now()
+WHERE id = $1
+  AND status = $7
+  AND captured_amount = $8
+  AND refunded_amount = $9
+RETURNING updated_at
+`
+	err := p.db.QueryRow(ctx, query,
+		payment.ID,
+		payment.Reference,
+		payment.Status,
+		payment.CapturedAmount,
+		payment.RefundedAmount,
+		payment.FailureReason,
+		previous.Status,
+		previous.CapturedAmount,
+		previous.RefundedAmount,
+	).Scan(&payment.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrPaymentChanged
+	}
+	return err
+}
+
+func (p *paymentRepositoryImpl) ExpirePending(ctx context.Context) (int64, error) {
+	const query = `
+UPDATE payment
+SET status         = $1,
+    failure_reason = $2,
+    updated_at     = now()
+WHERE status = $3
+  AND created_at <= $4
+`
+	tag, err := p.db.Exec(ctx, query, model.PaymentFailed, model.PaymentExpiredReason, model.PaymentPending,
+		time.Now().Add(-model.PaymentPendingTTL))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
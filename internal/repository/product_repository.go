@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go_store/internal/model"
+	"math"
+	"strings"
+	"unicode"
 )
 
 var _ ProductRepository = (*productRepositoryImpl)(nil)
@@ -18,12 +22,12 @@ func NewProductRepository(db *pgxpool.Pool) ProductRepository {
 
 func (p *productRepositoryImpl) Create(ctx context.Context, product *model.Product) (string, error) {
 	const query = `
-INSERT INTO product (name, description, price)
-VALUES ($1, $2, $3)
+INSERT INTO product (name, description, price, stock, tax_class, weight)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING id
 `
 	var result string
-	err := p.db.QueryRow(ctx, query, product.Name, product.Description, product.Price).
+	err := p.db.QueryRow(ctx, query, product.Name, product.Description, product.Price, product.Stock, product.TaxClass, product.Weight).
 		Scan(&result)
 	if err != nil {
 		return "", err
@@ -33,34 +37,288 @@ RETURNING id
 
 func (p *productRepositoryImpl) GetByID(ctx context.Context, id string) (*model.Product, error) {
 	const query = `
-SELECT id, name, description, price FROM product WHERE id = $1
+SELECT id, name, description, price, stock, tax_class, weight, archived_at FROM product WHERE id = $1 AND archived_at IS NULL
 `
 	var product model.Product
 	err := p.db.QueryRow(ctx, query, id).Scan(
-		&product.ID, &product.Name, &product.Description, &product.Price,
+		&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &product.TaxClass, &product.Weight, &product.ArchivedAt,
 	)
 	if err != nil {
+		return nil, mapError(err, "product", id)
+	}
+	products := []model.Product{product}
+	if err = p.loadCategories(ctx, products); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+func (p *productRepositoryImpl) Update(ctx context.Context, product *model.Product, fields []string) (*model.Product, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+
+	assignments := make([]string, 0, len(fields))
+	args := make([]any, 0, len(fields)+1)
+	for _, field := range fields {
+		var value any
+		switch field {
+		case model.ProductFieldName:
+			value = product.Name
+		case model.ProductFieldDescription:
+			value = product.Description
+		case model.ProductFieldPrice:
+			value = product.Price
+		case model.ProductFieldTaxClass:
+			value = product.TaxClass
+		case model.ProductFieldWeight:
+			value = product.Weight
+		default:
+			return nil, fmt.Errorf("unknown product field %q", field)
+		}
+		args = append(args, value)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", field, len(args)))
+	}
+	args = append(args, product.ID)
+
+	query := fmt.Sprintf(`
+UPDATE product
+SET %s
+WHERE id = $%d
+RETURNING id, name, description, price, stock, tax_class, weight, archived_at
+`, strings.Join(assignments, ", "), len(args))
+
+	var result model.Product
+	err := p.db.QueryRow(ctx, query, args...).Scan(
+		&result.ID, &result.Name, &result.Description, &result.Price, &result.Stock, &result.TaxClass, &result.Weight, &result.ArchivedAt,
+	)
+	if err != nil {
+		return nil, mapError(err, "product", product.ID)
+	}
+	products := []model.Product{result}
+	if err = p.loadCategories(ctx, products); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+func (p *productRepositoryImpl) Archive(ctx context.Context, id string) error {
+	const query = `
+UPDATE product
+SET archived_at = COALESCE(archived_at, now())
+WHERE id = $1
+`
+	tag, err := p.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "product", ID: id}
+	}
+	return nil
+}
+
+func (p *productRepositoryImpl) Restore(ctx context.Context, id string) error {
+	const query = `
+UPDATE product
+SET archived_at = NULL
+WHERE id = $1
+`
+	tag, err := p.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "product", ID: id}
+	}
+	return nil
+}
+
+func (p *productRepositoryImpl) SetStock(ctx context.Context, id string, stock int32) (int32, error) {
+	const query = `
+UPDATE product
+SET stock = $1
+WHERE id = $2
+RETURNING stock
+`
+	var result int32
+	if err := p.db.QueryRow(ctx, query, stock, id).Scan(&result); err != nil {
+		return 0, mapError(err, "product", id)
+	}
+	return result, nil
+}
+
+func (p *productRepositoryImpl) AdjustStock(ctx context.Context, id string, delta int32) (int32, error) {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const selectQuery = `
+SELECT stock FROM product WHERE id = $1 FOR UPDATE
+`
+	var stock int32
+	if err = tx.QueryRow(ctx, selectQuery, id).Scan(&stock); err != nil {
+		return 0, mapError(err, "product", id)
+	}
+
+	// Computed in int64, so a large delta can not wrap around the checks.
+	newStock := int64(stock) + int64(delta)
+	if newStock < 0 {
+		return 0, &model.OutOfStockError{Items: []model.OutOfStockItem{{
+			ProductID: id,
+			Requested: -int64(delta),
+			Available: stock,
+		}}}
+	}
+	if newStock > math.MaxInt32 {
+		return 0, &model.InvalidArgumentError{Field: "delta", Description: "stock would exceed the maximum"}
+	}
+
+	const updateQuery = `
+UPDATE product
+SET stock = stock + $1
+WHERE id = $2
+RETURNING stock
+`
+	if err = tx.QueryRow(ctx, updateQuery, delta, id).Scan(&stock); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return stock, nil
+}
+
+func (p *productRepositoryImpl) List(ctx context.Context, filter model.ProductFilter, after *model.ProductCursor, limit, offset int32) ([]model.Product, error) {
+	var args queryArgs
+	var with string
+	conditions := []string{"archived_at IS NULL"}
+
+	if filter.CategoryID != "" {
+		with = fmt.Sprintf(`
+WITH RECURSIVE selected AS (
+    SELECT id FROM category WHERE id = %s
+    UNION
+    SELECT c.id FROM category c JOIN selected s ON c.parent_id = s.id WHERE %s
+)`, args.add(filter.CategoryID), args.add(filter.IncludeDescendants))
+		conditions = append(conditions, `EXISTS(SELECT 1
+             FROM product_category pc
+             JOIN selected s ON s.id = pc.category_id
+             WHERE pc.product_id = p.id)`)
+	}
+
+	if after != nil {
+		conditions = append(conditions, fmt.Sprintf("(name, id) > (%s, %s)", args.add(after.Name), args.add(after.ID)))
+	}
+
+	query := fmt.Sprintf(`%s
+SELECT id, name, description, price, stock, tax_class, weight, archived_at
+FROM product p
+WHERE %s
+ORDER BY name, id
+LIMIT %s OFFSET %s
+`, with, strings.Join(conditions, "\n  AND "), args.add(limit), args.add(offset))
+
+	return p.query(ctx, query, args...)
+}
+
+func (p *productRepositoryImpl) Search(ctx context.Context, text string, limit, offset int32) ([]model.ProductSearchResult, error) {
+	tsQuery := prefixTSQuery(text)
+	if tsQuery == "" {
+		return nil, nil
+	}
+
+	// The text is HTML-escaped before ts_headline adds its tags, so the highlights are safe to render as HTML.
+	const query = `
+SELECT id, name, description, price, stock, tax_class, weight, archived_at,
+       ts_rank_cd(search_vector, q) AS rank,
+       ts_headline('russian',
+                   replace(replace(replace(replace(name, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'),
+                   q, 'HighlightAll=true'),
+       ts_headline('russian',
+                   replace(replace(replace(replace(coalesce(description, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'),
+                   q, 'MaxFragments=2, MaxWords=30, MinWords=10')
+FROM product, to_tsquery('russian', $1) q
+WHERE archived_at IS NULL
+  AND search_vector @@ q
+ORDER BY rank DESC, name, id
+LIMIT $2 OFFSET $3
+`
+	rows, err := p.db.Query(ctx, query, tsQuery, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.ProductSearchResult
+	for rows.Next() {
+		var r model.ProductSearchResult
+		err = rows.Scan(
+			&r.Product.ID, &r.Product.Name, &r.Product.Description, &r.Product.Price, &r.Product.Stock, &r.Product.TaxClass, &r.Product.Weight, &r.Product.ArchivedAt,
+			&r.Rank, &r.NameHighlight, &r.DescriptionHighlight,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	products := make([]model.Product, 0, len(results))
+	for _, r := range results {
+		products = append(products, r.Product)
+	}
+	if err = p.loadCategories(ctx, products); err != nil {
 		return nil, err
 	}
-	return &product, nil
+	for i := range results {
+		results[i].Product = products[i]
+	}
+	return results, nil
 }
 
-func (p *productRepositoryImpl) Delete(ctx context.Context, id string) error {
+// prefixTSQuery turns user input into a tsquery that requires every word to match by prefix.
+// Everything except letters and digits is dropped, so the input can not inject tsquery operators.
+func prefixTSQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+	return strings.Join(terms, " & ")
+}
+
+func (p *productRepositoryImpl) GetByIDs(ctx context.Context, ids []string) ([]model.Product, error) {
 	const query = `
-DELETE FROM product WHERE id = $1
+SELECT id, name, description, price, stock, tax_class, weight, archived_at
+FROM product
+WHERE id = ANY($1) AND archived_at IS NULL
 `
-	_, err := p.db.Exec(ctx, query, id)
-	return err
+	return p.query(ctx, query, ids)
 }
 
-func (p *productRepositoryImpl) List(ctx context.Context, limit, offset int32) ([]model.Product, error) {
+func (p *productRepositoryImpl) ListArchived(ctx context.Context, limit, offset int32) ([]model.Product, error) {
 	const query = `
-SELECT id, name, description, price 
-FROM product 
-ORDER BY name 
+SELECT id, name, description, price, stock, tax_class, weight, archived_at
+FROM product
+WHERE archived_at IS NOT NULL
+ORDER BY archived_at DESC, id
 LIMIT $1 OFFSET $2
 `
-	rows, err := p.db.Query(ctx, query, limit, offset)
+	return p.query(ctx, query, limit, offset)
+}
+
+func (p *productRepositoryImpl) query(ctx context.Context, query string, args ...any) ([]model.Product, error) {
+	rows, err := p.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +327,7 @@ LIMIT $1 OFFSET $2
 	var products []model.Product
 	for rows.Next() {
 		var p model.Product
-		if err = rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price); err != nil {
+		if err = rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.Stock, &p.TaxClass, &p.Weight, &p.ArchivedAt); err != nil {
 			return nil, err
 		}
 		products = append(products, p)
@@ -77,5 +335,46 @@ LIMIT $1 OFFSET $2
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
+
+	if err = p.loadCategories(ctx, products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
+
+// loadCategories fills CategoryIDs of the given products.
+func (p *productRepositoryImpl) loadCategories(ctx context.Context, products []model.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	productIDs := make([]string, 0, len(products))
+	byID := make(map[string]*model.Product, len(products))
+	for i := range products {
+		products[i].CategoryIDs = []string{}
+		productIDs = append(productIDs, products[i].ID)
+		byID[products[i].ID] = &products[i]
+	}
+
+	const query = `
+SELECT product_id, category_id
+FROM product_category
+WHERE product_id = ANY($1)
+`
+	rows, err := p.db.Query(ctx, query, productIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, categoryID string
+		if err = rows.Scan(&productID, &categoryID); err != nil {
+			return err
+		}
+		if product, ok := byID[productID]; ok {
+			product.CategoryIDs = append(product.CategoryIDs, categoryID)
+		}
+	}
+	return rows.Err()
+}
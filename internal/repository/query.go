@@ -0,0 +1,12 @@
+package repository
+
+import "fmt"
+
+// queryArgs collects arguments of a dynamically built query.
+type queryArgs []any
+
+// add appends the value and returns its placeholder.
+func (a *queryArgs) add(value any) string {
+	*a = append(*a, value)
+	return fmt.Sprintf("$%d", len(*a))
+}
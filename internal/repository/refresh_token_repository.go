@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go_store/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ RefreshTokenRepository = (*refreshTokenRepositoryImpl)(nil)
+
+type refreshTokenRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool) RefreshTokenRepository {
+	return &refreshTokenRepositoryImpl{db: db}
+}
+
+func (r *refreshTokenRepositoryImpl) Create(ctx context.Context, token *model.RefreshToken) error {
+	const query = `
+INSERT INTO admin_refresh_token (admin_id, family_id, token_hash, expires_at)
+VALUES ($1, uuid_generate_v4(), $2, $3)
+RETURNING id, family_id
+`
+	return r.db.QueryRow(ctx, query, token.AdminID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.FamilyID)
+}
+
+func (r *refreshTokenRepositoryImpl) Rotate(ctx context.Context, tokenHash string, next *model.RefreshToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const selectQuery = `
+SELECT t.id, t.admin_id, t.family_id, t.expires_at, t.revoked_at, a.disabled_at
+FROM admin_refresh_token t
+JOIN admin_user a ON a.id = t.admin_id
+WHERE t.token_hash = $1
+FOR UPDATE OF t
+`
+	var current model.RefreshToken
+	var revokedAt, disabledAt *time.Time
+	err = tx.QueryRow(ctx, selectQuery, tokenHash).Scan(
+		&current.ID, &current.AdminID, &current.FamilyID, &current.ExpiresAt, &revokedAt, &disabledAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if revokedAt != nil {
+		// A rotated token is used again: either the client or an attacker holds a stolen copy,
+		// so the whole family is revoked and the administrator has to log in again.
+		const revokeFamilyQuery = `
+UPDATE admin_refresh_token
+SET revoked_at = now()
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+		if _, err = tx.Exec(ctx, revokeFamilyQuery, current.FamilyID); err != nil {
+			return err
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return err
+		}
+		return model.ErrRefreshTokenReused
+	}
+	if disabledAt != nil || !current.ExpiresAt.After(time.Now()) {
+		return model.ErrInvalidRefreshToken
+	}
+
+	const revokeQuery = `
+UPDATE admin_refresh_token
+SET revoked_at = now()
+WHERE id = $1
+`
+	if _, err = tx.Exec(ctx, revokeQuery, current.ID); err != nil {
+		return err
+	}
+
+	const insertQuery = `
+INSERT INTO admin_refresh_token (admin_id, family_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id
+`
+	next.AdminID = current.AdminID
+	next.FamilyID = current.FamilyID
+	err = tx.QueryRow(ctx, insertQuery, next.AdminID, next.FamilyID, next.TokenHash, next.ExpiresAt).Scan(&next.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *refreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, tokenHash string, username string) error {
+	const query = `
+UPDATE admin_refresh_token
+SET revoked_at = now()
+WHERE revoked_at IS NULL
+  AND family_id = (SELECT t.family_id
+                   FROM admin_refresh_token t
+                   JOIN admin_user a ON a.id = t.admin_id
+                   WHERE t.token_hash = $1 AND a.username = $2)
+`
+	_, err := r.db.Exec(ctx, query, tokenHash, username)
+	return err
+}
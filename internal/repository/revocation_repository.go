@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"go_store/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ RevocationRepository = (*revocationRepositoryImpl)(nil)
+
+type revocationRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRevocationRepository(db *pgxpool.Pool) RevocationRepository {
+	return &revocationRepositoryImpl{db: db}
+}
+
+func (r *revocationRepositoryImpl) RevokeToken(ctx context.Context, token *model.RevokedToken) error {
+	const query = `
+INSERT INTO revoked_token (id, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (id) DO NOTHING
+`
+	_, err := r.db.Exec(ctx, query, token.ID, token.ExpiresAt)
+	return err
+}
+
+func (r *revocationRepositoryImpl) RevokeSessions(ctx context.Context, adminID string) (*model.SessionRevocation, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const adminQuery = `
+UPDATE admin_user
+SET sessions_revoked_at = now()
+WHERE id = $1
+RETURNING username, sessions_revoked_at
+`
+	var result model.SessionRevocation
+	if err = tx.QueryRow(ctx, adminQuery, adminID).Scan(&result.Username, &result.RevokedAt); err != nil {
+		return nil, mapError(err, "admin user", adminID)
+	}
+
+	const refreshTokensQuery = `
+UPDATE admin_refresh_token
+SET revoked_at = now()
+WHERE admin_id = $1 AND revoked_at IS NULL
+`
+	if _, err = tx.Exec(ctx, refreshTokensQuery, adminID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *revocationRepositoryImpl) ListRevokedTokens(ctx context.Context) ([]model.RevokedToken, error) {
+	const query = `
+SELECT id, expires_at
+FROM revoked_token
+WHERE expires_at > now()
+`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []model.RevokedToken
+	for rows.Next() {
+		var token model.RevokedToken
+		if err = rows.Scan(&token.ID, &token.ExpiresAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *revocationRepositoryImpl) ListSessionRevocations(ctx context.Context, since time.Time) ([]model.SessionRevocation, error) {
+	const query = `
+SELECT username, sessions_revoked_at
+FROM admin_user
+WHERE sessions_revoked_at > $1
+`
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revocations []model.SessionRevocation
+	for rows.Next() {
+		var revocation model.SessionRevocation
+		if err = rows.Scan(&revocation.Username, &revocation.RevokedAt); err != nil {
+			return nil, err
+		}
+		revocations = append(revocations, revocation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return revocations, nil
+}
+
+func (r *revocationRepositoryImpl) DeleteExpired(ctx context.Context) error {
+	const query = `
+DELETE FROM revoked_token WHERE expires_at <= now()
+`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"go_store/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ ShippingRepository = (*shippingRepositoryImpl)(nil)
+
+type shippingRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewShippingRepository(db *pgxpool.Pool) ShippingRepository {
+	return &shippingRepositoryImpl{db: db}
+}
+
+func (s *shippingRepositoryImpl) CreateMethod(ctx context.Context, method *model.ShippingMethod) (string, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const methodInsert = `
+INSERT INTO shipping_method (name, description, rate_type, flat_rate, free_over, countries)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id
+`
+	var id string
+	err = tx.QueryRow(ctx, methodInsert, method.Name, method.Description, method.RateType, method.FlatRate, method.FreeOver, method.Countries).
+		Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	const rateInsert = `
+INSERT INTO shipping_rate (method_id, min_value, rate)
+VALUES ($1, $2, $3)
+`
+	for _, rate := range method.Rates {
+		if _, err = tx.Exec(ctx, rateInsert, id, rate.MinValue, rate.Rate); err != nil {
+			return "", mapError(err, "shipping rate", "")
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *shippingRepositoryImpl) GetMethod(ctx context.Context, id string) (*model.ShippingMethod, error) {
+	const query = `
+SELECT id, name, description, rate_type, flat_rate, free_over, countries, created_at, disabled_at
+FROM shipping_method
+WHERE id = $1
+`
+	methods, err := s.query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) == 0 {
+		return nil, &model.NotFoundError{Entity: "shipping method", ID: id}
+	}
+	return &methods[0], nil
+}
+
+func (s *shippingRepositoryImpl) ListMethods(ctx context.Context, includeDisabled bool) ([]model.ShippingMethod, error) {
+	const query = `
+SELECT id, name, description, rate_type, flat_rate, free_over, countries, created_at, disabled_at
+FROM shipping_method
+WHERE $1 OR disabled_at IS NULL
+ORDER BY name, id
+`
+	return s.query(ctx, query, includeDisabled)
+}
+
+func (s *shippingRepositoryImpl) DisableMethod(ctx context.Context, id string) error {
+	const query = `
+UPDATE shipping_method
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+`
+	tag, err := s.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "shipping method", ID: id}
+	}
+	return nil
+}
+
+// query reads shipping methods with their rate tables.
+func (s *shippingRepositoryImpl) query(ctx context.Context, query string, args ...any) ([]model.ShippingMethod, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	methods := []model.ShippingMethod{}
+	methodIndex := make(map[string]int)
+	for rows.Next() {
+		var m model.ShippingMethod
+		err = rows.Scan(&m.ID, &m.Name, &m.Description, &m.RateType, &m.FlatRate, &m.FreeOver, &m.Countries, &m.CreatedAt, &m.DisabledAt)
+		if err != nil {
+			return nil, err
+		}
+		m.Rates = []model.ShippingRate{}
+		methodIndex[m.ID] = len(methods)
+		methods = append(methods, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(methods) == 0 {
+		return methods, nil
+	}
+
+	const ratesQuery = `
+SELECT method_id, min_value, rate
+FROM shipping_rate
+WHERE method_id = ANY($1)
+ORDER BY method_id, min_value
+`
+	methodIDs := make([]string, 0, len(methods))
+	for _, m := range methods {
+		methodIDs = append(methodIDs, m.ID)
+	}
+	rateRows, err := tx.Query(ctx, ratesQuery, methodIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rateRows.Close()
+
+	for rateRows.Next() {
+		var rate model.ShippingRate
+		var methodID string
+		if err = rateRows.Scan(&methodID, &rate.MinValue, &rate.Rate); err != nil {
+			return nil, err
+		}
+		if i, ok := methodIndex[methodID]; ok {
+			methods[i].Rates = append(methods[i].Rates, rate)
+		}
+	}
+	if err = rateRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return methods, nil
+}
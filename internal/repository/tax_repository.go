@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"go_store/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ TaxRepository = (*taxRepositoryImpl)(nil)
+
+type taxRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewTaxRepository(db *pgxpool.Pool) TaxRepository {
+	return &taxRepositoryImpl{db: db}
+}
+
+func (t *taxRepositoryImpl) CreateRule(ctx context.Context, rule *model.TaxRule) (string, error) {
+	const query = `
+INSERT INTO tax_rule (country, region, tax_class, name, rate, inclusive)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id
+`
+	var id string
+	err := t.db.QueryRow(ctx, query, rule.Country, rule.Region, rule.TaxClass, rule.Name, rule.Rate, rule.Inclusive).Scan(&id)
+	if err != nil {
+		return "", mapError(err, "tax rule", "")
+	}
+	return id, nil
+}
+
+func (t *taxRepositoryImpl) ListRules(ctx context.Context, country string) ([]model.TaxRule, error) {
+	const query = `
+SELECT id, country, region, tax_class, name, rate, inclusive, created_at
+FROM tax_rule
+WHERE $1 = '' OR country = $1
+ORDER BY country, region, tax_class
+`
+	rows, err := t.db.Query(ctx, query, country)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []model.TaxRule{}
+	for rows.Next() {
+		var rule model.TaxRule
+		err = rows.Scan(&rule.ID, &rule.Country, &rule.Region, &rule.TaxClass, &rule.Name, &rule.Rate, &rule.Inclusive, &rule.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (t *taxRepositoryImpl) DeleteRule(ctx context.Context, id string) error {
+	const query = `
+DELETE FROM tax_rule WHERE id = $1
+`
+	tag, err := t.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &model.NotFoundError{Entity: "tax rule", ID: id}
+	}
+	return nil
+}
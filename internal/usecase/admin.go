@@ -2,41 +2,252 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 	"go_store/config"
+	"go_store/internal/jwtkeys"
+	"go_store/internal/model"
+	"go_store/internal/repository"
 	"golang.org/x/crypto/bcrypt"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"time"
 )
 
 var _ AdminUseCase = (*adminUseCase)(nil)
 
 type adminUseCase struct {
-	logger *zap.Logger
-	cfg    *config.Admin
+	logger                 *zap.Logger
+	cfg                    *config.Admin
+	keys                   *jwtkeys.KeySet
+	adminRepository        repository.AdminRepository
+	refreshTokenRepository repository.RefreshTokenRepository
+	denylist               TokenDenylist
+	throttle               LoginThrottle
+	challengeRepository    repository.LoginChallengeRepository
 }
 
-func NewAdminUseCase(logger *zap.Logger, cfg *config.Admin) AdminUseCase {
-	return &adminUseCase{logger: logger, cfg: cfg}
+func NewAdminUseCase(
+	logger *zap.Logger,
+	cfg *config.Admin,
+	keys *jwtkeys.KeySet,
+	adminRepository repository.AdminRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	denylist TokenDenylist,
+	throttle LoginThrottle,
+	challengeRepository repository.LoginChallengeRepository,
+) AdminUseCase {
+	return &adminUseCase{
+		logger:                 logger,
+		cfg:                    cfg,
+		keys:                   keys,
+		adminRepository:        adminRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		denylist:               denylist,
+		throttle:               throttle,
+		challengeRepository:    challengeRepository,
+	}
+}
+
+func (a *adminUseCase) EnsureSuperuser(ctx context.Context) error {
+	if a.cfg.Username == "" || a.cfg.PasswordHash == "" {
+		return nil
+	}
+	created, err := a.adminRepository.CreateIfNone(ctx, &model.AdminUser{
+		Username:     a.cfg.Username,
+		PasswordHash: a.cfg.PasswordHash,
+		Roles:        []model.AdminRole{model.AdminRoleSuperuser},
+	})
+	if err != nil {
+		return err
+	}
+	if created {
+		a.logger.Info("initial superuser created", zap.String("username", a.cfg.Username))
+	}
+	return nil
+}
+
+func (a *adminUseCase) Login(ctx context.Context, username string, password string, clientIP string) (*model.AdminTokens, error) {
+	if err := a.throttle.Check(ctx, username, clientIP); err != nil {
+		return nil, err
+	}
+
+	admin, err := a.authenticate(ctx, username, password)
+	if errors.Is(err, model.ErrInvalidCredentials) {
+		if throttleErr := a.throttle.Failure(ctx, username, clientIP); throttleErr != nil {
+			return nil, throttleErr
+		}
+		return nil, model.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if admin.TOTPEnabled() {
+		return a.newLoginChallenge(ctx, admin)
+	}
+	if err = a.throttle.Success(ctx, username); err != nil {
+		return nil, err
+	}
+	return a.issueTokens(ctx, admin)
+}
+
+// issueTokens starts a new session of the administrator.
+func (a *adminUseCase) issueTokens(ctx context.Context, admin *model.AdminUser) (*model.AdminTokens, error) {
+	tokens, err := a.newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	err = a.refreshTokenRepository.Create(ctx, &model.RefreshToken{
+		AdminID:   admin.ID,
+		TokenHash: hashToken(tokens.RefreshToken),
+		ExpiresAt: tokens.RefreshTokenExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.signAccessToken(admin, tokens)
 }
 
-func (a *adminUseCase) Login(_ context.Context, username string, password string) (string, error) {
-	if username != a.cfg.Username {
+func (a *adminUseCase) Refresh(ctx context.Context, refreshToken string) (*model.AdminTokens, error) {
+	tokens, err := a.newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	record := &model.RefreshToken{TokenHash: hashToken(tokens.RefreshToken), ExpiresAt: tokens.RefreshTokenExpiresAt}
+	err = a.refreshTokenRepository.Rotate(ctx, hashToken(refreshToken), record)
+	if errors.Is(err, model.ErrRefreshTokenReused) {
+		a.logger.Warn("refresh token reuse detected, token family revoked")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := a.adminRepository.GetByID(ctx, record.AdminID)
+	if err != nil {
+		return nil, err
+	}
+	return a.signAccessToken(admin, tokens)
+}
+
+// authenticate checks the credentials. Unknown usernames, wrong passwords and disabled accounts
+// all return model.ErrInvalidCredentials.
+func (a *adminUseCase) authenticate(ctx context.Context, username string, password string) (*model.AdminUser, error) {
+	admin, err := a.adminRepository.GetByUsername(ctx, username)
+	var notFound *model.NotFoundError
+	if errors.As(err, &notFound) {
+		compareDummyPassword(password)
 		a.logger.Warn("invalid username", zap.String("username", username))
-		return "", status.Error(codes.Unauthenticated, "wrong credentials")
+		return nil, model.ErrInvalidCredentials
 	}
-	err := bcrypt.CompareHashAndPassword([]byte(a.cfg.PasswordHash), []byte(password))
 	if err != nil {
-		a.logger.Warn("wrong password", zap.String("password", password), zap.String("password hash", a.cfg.PasswordHash), zap.Error(err))
-		return "", status.Error(codes.Unauthenticated, "wrong credentials")
+		return nil, err
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(password)); err != nil {
+		a.logger.Warn("wrong password", zap.String("username", username))
+		return nil, model.ErrInvalidCredentials
+	}
+	if admin.DisabledAt != nil {
+		a.logger.Warn("disabled admin tried to log in", zap.String("username", username))
+		return nil, model.ErrInvalidCredentials
 	}
+	return admin, nil
+}
 
-	token := jwt.New(jwt.SigningMethodHS256)
+// newRefreshToken generates a refresh token. The access token is filled in by signAccessToken.
+func (a *adminUseCase) newRefreshToken() (*model.AdminTokens, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AdminTokens{
+		RefreshToken:          token,
+		RefreshTokenExpiresAt: time.Now().Add(a.cfg.RefreshTokenTTL),
+	}, nil
+}
 
-	tokenString, err := token.SignedString([]byte(a.cfg.JWTSecret))
+func (a *adminUseCase) signAccessToken(admin *model.AdminUser, tokens *model.AdminTokens) (*model.AdminTokens, error) {
+	id, err := randomToken(16)
 	if err != nil {
-		return "", status.Error(codes.Unauthenticated, "invalid token")
+		return nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(a.cfg.AccessTokenTTL)
+
+	tokens.AccessToken, err = a.keys.Sign(model.AdminClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   admin.Username,
+			Issuer:    a.cfg.JWTIssuer,
+			Audience:  jwt.ClaimStrings{a.cfg.JWTAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Roles: admin.Roles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tokens.AccessTokenExpiresAt = expiresAt
+	return tokens, nil
+}
+
+func (a *adminUseCase) Create(ctx context.Context, username string, password string, roles []model.AdminRole) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return a.adminRepository.Create(ctx, &model.AdminUser{
+		Username:     username,
+		PasswordHash: string(hash),
+		Roles:        roles,
+	})
+}
+
+func (a *adminUseCase) Disable(ctx context.Context, id string, disabledBy string) error {
+	current, err := a.adminRepository.GetByUsername(ctx, disabledBy)
+	if err != nil {
+		return err
+	}
+	if current.ID == id {
+		return &model.InvalidArgumentError{Field: "id", Description: "administrators can not disable themselves"}
+	}
+	if err = a.adminRepository.Disable(ctx, id); err != nil {
+		return err
 	}
-	return tokenString, nil
+	return a.denylist.RevokeSessions(ctx, id)
+}
+
+func (a *adminUseCase) List(ctx context.Context, limit, offset int32) ([]model.AdminUser, error) {
+	return a.adminRepository.List(ctx, pageSize(limit), offset)
+}
+
+func (a *adminUseCase) ChangePassword(ctx context.Context, username string, currentPassword string, newPassword string) error {
+	admin, err := a.adminRepository.GetByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(currentPassword)); err != nil {
+		return &model.InvalidArgumentError{Field: "current_password", Description: "wrong password"}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return a.adminRepository.UpdatePasswordHash(ctx, admin.ID, string(hash))
+}
+
+func (a *adminUseCase) Logout(ctx context.Context, claims *model.AdminClaims, refreshToken string) error {
+	if refreshToken != "" {
+		if err := a.refreshTokenRepository.RevokeFamily(ctx, hashToken(refreshToken), claims.Subject); err != nil {
+			return err
+		}
+	}
+	return a.denylist.Revoke(ctx, claims)
+}
+
+func (a *adminUseCase) RevokeSessions(ctx context.Context, id string) error {
+	return a.denylist.RevokeSessions(ctx, id)
+}
+
+func (a *adminUseCase) UnlockLogin(ctx context.Context, username string, clientIP string) error {
+	return a.throttle.Unlock(ctx, username, clientIP)
 }
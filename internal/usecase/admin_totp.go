@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go_store/internal/model"
+	"go_store/internal/totp"
+	"strings"
+	"time"
+)
+
+const (
+	loginChallengeTTL         = 5 * time.Minute
+	loginChallengeMaxAttempts = 5
+	recoveryCodeCount         = 10
+)
+
+var errInvalidCode = &model.InvalidArgumentError{Field: "code", Description: "invalid two-factor code"}
+
+// newLoginChallenge is the first step of login with two-factor authentication.
+func (a *adminUseCase) newLoginChallenge(ctx context.Context, admin *model.AdminUser) (*model.AdminTokens, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	challenge := &model.LoginChallenge{
+		AdminID:   admin.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(loginChallengeTTL),
+	}
+	if err = a.challengeRepository.Create(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return &model.AdminTokens{Challenge: token, ChallengeExpiresAt: challenge.ExpiresAt}, nil
+}
+
+func (a *adminUseCase) CompleteLogin(ctx context.Context, challengeToken string, code string, recoveryCode string, clientIP string) (*model.AdminTokens, error) {
+	challenge, err := a.challengeRepository.Attempt(ctx, hashToken(challengeToken), loginChallengeMaxAttempts)
+	var notFound *model.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, model.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := a.adminRepository.GetByID(ctx, challenge.AdminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin.DisabledAt != nil || !admin.TOTPEnabled() {
+		return nil, model.ErrInvalidCredentials
+	}
+
+	ok, err := a.throttled(ctx, admin.Username, clientIP, func() (bool, error) {
+		return a.verifySecondFactor(ctx, admin, code, recoveryCode)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, model.ErrInvalidCredentials
+	}
+
+	if err = a.challengeRepository.Delete(ctx, challenge.ID); err != nil {
+		return nil, err
+	}
+	return a.issueTokens(ctx, admin)
+}
+
+// throttled checks a two-factor code with verify under the login throttle, so that codes can not be
+// guessed faster than passwords.
+func (a *adminUseCase) throttled(ctx context.Context, username string, clientIP string, verify func() (bool, error)) (bool, error) {
+	if err := a.throttle.Check(ctx, username, clientIP); err != nil {
+		return false, err
+	}
+	ok, err := verify()
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		a.logger.Warn("wrong two-factor code")
+		return false, a.throttle.Failure(ctx, username, clientIP)
+	}
+	return true, a.throttle.Success(ctx, username)
+}
+
+// verifySecondFactor checks a one-time code or, if it is empty, a recovery code.
+// Both can be used only once.
+func (a *adminUseCase) verifySecondFactor(ctx context.Context, admin *model.AdminUser, code string, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := totp.Validate(admin.TOTPSecret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return a.adminRepository.UseTOTPStep(ctx, admin.ID, step)
+	}
+	if recoveryCode != "" {
+		return a.adminRepository.UseRecoveryCode(ctx, admin.ID, hashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+	return false, nil
+}
+
+func (a *adminUseCase) EnrollTOTP(ctx context.Context, username string) (*model.TOTPEnrollment, error) {
+	admin, err := a.adminRepository.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TOTPEnabled() {
+		return nil, model.ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err = a.adminRepository.SetTOTPSecret(ctx, admin.ID, secret); err != nil {
+		return nil, err
+	}
+	return &model.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(a.cfg.JWTIssuer, admin.Username, secret),
+	}, nil
+}
+
+func (a *adminUseCase) ConfirmTOTP(ctx context.Context, username string, code string, clientIP string) ([]string, error) {
+	admin, err := a.adminRepository.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TOTPEnabled() {
+		return nil, model.ErrTOTPAlreadyEnabled
+	}
+	if admin.TOTPSecret == "" {
+		return nil, model.ErrTOTPNotEnrolled
+	}
+
+	var step int64
+	ok, err := a.throttled(ctx, admin.Username, clientIP, func() (bool, error) {
+		var valid bool
+		step, valid = totp.Validate(admin.TOTPSecret, code, time.Now())
+		return valid, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errInvalidCode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	if err = a.adminRepository.EnableTOTP(ctx, admin.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (a *adminUseCase) DisableTOTP(ctx context.Context, username string, code string, recoveryCode string, clientIP string) error {
+	admin, err := a.adminRepository.GetByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !admin.TOTPEnabled() {
+		return model.ErrTOTPNotEnrolled
+	}
+
+	ok, err := a.throttled(ctx, admin.Username, clientIP, func() (bool, error) {
+		return a.verifySecondFactor(ctx, admin, code, recoveryCode)
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidCode
+	}
+	return a.adminRepository.DisableTOTP(ctx, admin.ID)
+}
+
+func (a *adminUseCase) ResetTOTP(ctx context.Context, id string) error {
+	return a.adminRepository.DisableTOTP(ctx, id)
+}
+
+// newRecoveryCode returns a code like "k3j5m-q8x2p".
+func newRecoveryCode() (string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	code := strings.ToLower(secret[:10])
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode makes recovery codes match regardless of case, spaces and dashes.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+)
+
+var _ CartUseCase = (*cartUseCaseImpl)(nil)
+
+type cartUseCaseImpl struct {
+	logger         *zap.Logger
+	cartRepository repository.CartRepository
+	orderUseCase   OrderUseCase
+}
+
+func NewCartUseCase(logger *zap.Logger, cartRepository repository.CartRepository, orderUseCase OrderUseCase) CartUseCase {
+	return &cartUseCaseImpl{
+		logger:         logger,
+		cartRepository: cartRepository,
+		orderUseCase:   orderUseCase,
+	}
+}
+
+func (c *cartUseCaseImpl) Create(ctx context.Context, customerID string) (*model.Cart, error) {
+	id, err := c.cartRepository.Create(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return c.cartRepository.GetByID(ctx, id)
+}
+
+func (c *cartUseCaseImpl) Get(ctx context.Context, id string, customerID string) (*model.Cart, error) {
+	cart, err := c.cartRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cart.CustomerID != "" && cart.CustomerID != customerID {
+		return nil, &model.NotFoundError{Entity: "cart", ID: id}
+	}
+	return cart, nil
+}
+
+func (c *cartUseCaseImpl) AddItem(ctx context.Context, id string, customerID string, productID string, quantity int32) (*model.Cart, error) {
+	if _, err := c.Get(ctx, id, customerID); err != nil {
+		return nil, err
+	}
+	if err := c.cartRepository.AddItem(ctx, id, productID, quantity); err != nil {
+		return nil, err
+	}
+	return c.cartRepository.GetByID(ctx, id)
+}
+
+func (c *cartUseCaseImpl) UpdateItem(ctx context.Context, id string, customerID string, productID string, quantity int32) (*model.Cart, error) {
+	if _, err := c.Get(ctx, id, customerID); err != nil {
+		return nil, err
+	}
+	if err := c.cartRepository.SetItem(ctx, id, productID, quantity); err != nil {
+		return nil, err
+	}
+	return c.cartRepository.GetByID(ctx, id)
+}
+
+func (c *cartUseCaseImpl) RemoveItem(ctx context.Context, id string, customerID string, productID string) (*model.Cart, error) {
+	if _, err := c.Get(ctx, id, customerID); err != nil {
+		return nil, err
+	}
+	if err := c.cartRepository.RemoveItem(ctx, id, productID); err != nil {
+		return nil, err
+	}
+	return c.cartRepository.GetByID(ctx, id)
+}
+
+func (c *cartUseCaseImpl) Checkout(ctx context.Context, id string, order *model.Order) (*model.PlacedOrder, error) {
+	if _, err := c.Get(ctx, id, order.CustomerID); err != nil {
+		return nil, err
+	}
+	// The items are read and removed from the cart in the transaction placing the order, so concurrent
+	// checkouts of the cart can not place it twice and items added meanwhile stay in the cart.
+	order.CartID = id
+	return c.orderUseCase.Create(ctx, order, "")
+}
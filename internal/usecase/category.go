@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+	"slices"
+)
+
+var _ CategoryUseCase = (*categoryUseCaseImpl)(nil)
+
+type categoryUseCaseImpl struct {
+	logger             *zap.Logger
+	categoryRepository repository.CategoryRepository
+}
+
+func NewCategoryUseCase(logger *zap.Logger, categoryRepository repository.CategoryRepository) CategoryUseCase {
+	return &categoryUseCaseImpl{
+		logger:             logger,
+		categoryRepository: categoryRepository,
+	}
+}
+
+func (c *categoryUseCaseImpl) Create(ctx context.Context, category *model.Category) (string, error) {
+	return c.categoryRepository.Create(ctx, category)
+}
+
+func (c *categoryUseCaseImpl) Update(ctx context.Context, category *model.Category, fields []string) (*model.Category, error) {
+	if slices.Contains(fields, model.CategoryFieldName) && category.Name == "" {
+		return nil, &model.InvalidArgumentError{Field: "name", Description: "must not be empty"}
+	}
+	return c.categoryRepository.Update(ctx, category, fields)
+}
+
+func (c *categoryUseCaseImpl) Delete(ctx context.Context, id string) error {
+	return c.categoryRepository.Delete(ctx, id)
+}
+
+func (c *categoryUseCaseImpl) List(ctx context.Context) ([]model.Category, error) {
+	return c.categoryRepository.List(ctx)
+}
+
+func (c *categoryUseCaseImpl) SetProductCategories(ctx context.Context, productID string, categoryIDs []string) error {
+	return c.categoryRepository.SetProductCategories(ctx, productID, categoryIDs)
+}
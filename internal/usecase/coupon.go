@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+)
+
+var _ CouponUseCase = (*couponUseCaseImpl)(nil)
+
+type couponUseCaseImpl struct {
+	logger           *zap.Logger
+	couponRepository repository.CouponRepository
+}
+
+func NewCouponUseCase(logger *zap.Logger, couponRepository repository.CouponRepository) CouponUseCase {
+	return &couponUseCaseImpl{
+		logger:           logger,
+		couponRepository: couponRepository,
+	}
+}
+
+func (c *couponUseCaseImpl) Create(ctx context.Context, coupon *model.Coupon) (string, error) {
+	switch coupon.Type {
+	case model.CouponPercentage:
+		if coupon.Value < 1 || coupon.Value > 100 {
+			return "", &model.InvalidArgumentError{Field: "value", Description: "must be between 1 and 100 for percentage coupons"}
+		}
+	case model.CouponFixedAmount:
+		if coupon.Value <= 0 {
+			return "", &model.InvalidArgumentError{Field: "value", Description: "must be positive for fixed amount coupons"}
+		}
+	case model.CouponFreeItem:
+		if coupon.FreeProductID == "" {
+			return "", &model.InvalidArgumentError{Field: "free_product_id", Description: "is required for free item coupons"}
+		}
+		coupon.Value = 0
+	default:
+		return "", &model.InvalidArgumentError{Field: "type", Description: "unknown coupon type"}
+	}
+	if coupon.Type != model.CouponFreeItem {
+		coupon.FreeProductID = ""
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return "", &model.InvalidArgumentError{Field: "ends_at", Description: "must be after starts_at"}
+	}
+
+	id, err := c.couponRepository.Create(ctx, coupon)
+	if err != nil {
+		return "", err
+	}
+	c.logger.Info("coupon created", zap.String("id", id), zap.String("code", coupon.Code))
+	return id, nil
+}
+
+func (c *couponUseCaseImpl) List(ctx context.Context, limit, offset int32) ([]model.Coupon, error) {
+	return c.couponRepository.List(ctx, pageSize(limit), offset)
+}
+
+func (c *couponUseCaseImpl) Disable(ctx context.Context, id string) error {
+	return c.couponRepository.Disable(ctx, id)
+}
@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"go_store/config"
+	"go_store/internal/jwtkeys"
+	"go_store/internal/mailer"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var _ CustomerUseCase = (*customerUseCaseImpl)(nil)
+
+type customerUseCaseImpl struct {
+	logger             *zap.Logger
+	cfg                *config.Customer
+	keys               *jwtkeys.KeySet
+	customerRepository repository.CustomerRepository
+	cartRepository     repository.CartRepository
+	throttle           LoginThrottle
+	// emailThrottle limits verification and password reset emails.
+	emailThrottle LoginThrottle
+	mailer        mailer.Mailer
+}
+
+func NewCustomerUseCase(
+	logger *zap.Logger,
+	cfg *config.Customer,
+	keys *jwtkeys.KeySet,
+	customerRepository repository.CustomerRepository,
+	cartRepository repository.CartRepository,
+	throttle LoginThrottle,
+	emailThrottle LoginThrottle,
+	mailer mailer.Mailer,
+) CustomerUseCase {
+	return &customerUseCaseImpl{
+		logger:             logger,
+		cfg:                cfg,
+		keys:               keys,
+		customerRepository: customerRepository,
+		cartRepository:     cartRepository,
+		throttle:           throttle,
+		emailThrottle:      emailThrottle,
+		mailer:             mailer,
+	}
+}
+
+// normalizeEmail makes emails that differ only in case or surrounding spaces equal.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (c *customerUseCaseImpl) Register(ctx context.Context, email string, password string, name string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	customer := &model.Customer{
+		Email:        normalizeEmail(email),
+		PasswordHash: string(hash),
+		Name:         name,
+	}
+	customer.ID, err = c.customerRepository.Create(ctx, customer)
+	if err != nil {
+		return "", err
+	}
+	// The account is usable without verification, and the link can be requested again.
+	c.inBackground(ctx, "verification", func(ctx context.Context) error {
+		return c.sendVerificationEmail(ctx, customer)
+	})
+	return customer.ID, nil
+}
+
+func (c *customerUseCaseImpl) Login(ctx context.Context, email string, password string, clientIP string, guestCartID string) (*model.CustomerToken, error) {
+	email = normalizeEmail(email)
+	if err := c.throttle.Check(ctx, email, clientIP); err != nil {
+		return nil, err
+	}
+
+	customer, err := c.authenticate(ctx, email, password)
+	if errors.Is(err, model.ErrInvalidCredentials) {
+		if throttleErr := c.throttle.Failure(ctx, email, clientIP); throttleErr != nil {
+			return nil, throttleErr
+		}
+		return nil, model.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = c.throttle.Success(ctx, email); err != nil {
+		return nil, err
+	}
+	if guestCartID != "" {
+		err = c.cartRepository.Merge(ctx, guestCartID, customer.ID)
+		var notFound *model.NotFoundError
+		if errors.As(err, &notFound) {
+			c.logger.Warn("guest cart not merged", zap.String("cart_id", guestCartID), zap.Error(err))
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return c.signAccessToken(customer)
+}
+
+// authenticate checks the credentials. Unknown emails and wrong passwords both return model.ErrInvalidCredentials.
+func (c *customerUseCaseImpl) authenticate(ctx context.Context, email string, password string) (*model.Customer, error) {
+	customer, err := c.customerRepository.GetByEmail(ctx, email)
+	var notFound *model.NotFoundError
+	if errors.As(err, &notFound) {
+		compareDummyPassword(password)
+		return nil, model.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(customer.PasswordHash), []byte(password)); err != nil {
+		c.logger.Warn("wrong customer password", zap.String("customer_id", customer.ID))
+		return nil, model.ErrInvalidCredentials
+	}
+	return customer, nil
+}
+
+func (c *customerUseCaseImpl) signAccessToken(customer *model.Customer) (*model.CustomerToken, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(c.cfg.AccessTokenTTL)
+
+	token, err := c.keys.Sign(model.CustomerClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   customer.ID,
+			Issuer:    c.cfg.JWTIssuer,
+			Audience:  jwt.ClaimStrings{c.cfg.JWTAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.CustomerToken{CustomerID: customer.ID, AccessToken: token, ExpiresAt: expiresAt}, nil
+}
+
+func (c *customerUseCaseImpl) Get(ctx context.Context, id string) (*model.Customer, error) {
+	return c.customerRepository.GetByID(ctx, id)
+}
+
+func (c *customerUseCaseImpl) IsTokenRevoked(ctx context.Context, claims *model.CustomerClaims) (bool, error) {
+	customer, err := c.customerRepository.GetByID(ctx, claims.Subject)
+	if err != nil {
+		var notFound *model.NotFoundError
+		if errors.As(err, &notFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	if customer.SessionsRevokedAt == nil || claims.IssuedAt == nil {
+		return customer.SessionsRevokedAt != nil, nil
+	}
+	// iat has a precision of a second, so tokens issued in the same second as the revocation are revoked too.
+	return !claims.IssuedAt.After(customer.SessionsRevokedAt.Truncate(time.Second)), nil
+}
+
+func (c *customerUseCaseImpl) Update(ctx context.Context, customer *model.Customer, fields []string) (*model.Customer, error) {
+	if slices.Contains(fields, model.CustomerFieldEmail) {
+		if customer.Email == "" {
+			return nil, &model.InvalidArgumentError{Field: "email", Description: "must not be empty"}
+		}
+		customer.Email = normalizeEmail(customer.Email)
+	}
+	updated, err := c.customerRepository.Update(ctx, customer, fields)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(fields, model.CustomerFieldEmail) && updated.EmailVerifiedAt == nil {
+		c.inBackground(ctx, "verification", func(ctx context.Context) error {
+			return c.sendVerificationEmail(ctx, updated)
+		})
+	}
+	return updated, nil
+}
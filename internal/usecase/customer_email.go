@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"go_store/internal/mailer"
+	"go_store/internal/model"
+	"golang.org/x/crypto/bcrypt"
+	"net/url"
+	"time"
+)
+
+var errInvalidEmailToken = &model.InvalidArgumentError{Field: "token", Description: "invalid or expired token"}
+
+// emailTokenAudience keeps tokens sent by email apart from access tokens and from each other.
+func (c *customerUseCaseImpl) emailTokenAudience(purpose model.EmailTokenPurpose) string {
+	return c.cfg.JWTAudience + "/" + string(purpose)
+}
+
+// issueEmailToken signs a token for the purpose and stores its ID, so that it can be used once.
+// It returns the token and its expiry.
+func (c *customerUseCaseImpl) issueEmailToken(ctx context.Context, customer *model.Customer, purpose model.EmailTokenPurpose, ttl time.Duration) (string, time.Time, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	token, err := c.keys.Sign(model.EmailTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   customer.ID,
+			Issuer:    c.cfg.JWTIssuer,
+			Audience:  jwt.ClaimStrings{c.emailTokenAudience(purpose)},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Email: customer.Email,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	err = c.customerRepository.CreateEmailToken(ctx, &model.EmailToken{
+		ID:         id,
+		CustomerID: customer.ID,
+		Purpose:    purpose,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// parseEmailToken checks the signature, expiry and purpose of a token sent by email.
+// Whether it was already used is checked by the repository.
+func (c *customerUseCaseImpl) parseEmailToken(token string, purpose model.EmailTokenPurpose) (*model.EmailTokenClaims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(c.keys.Methods()),
+		jwt.WithIssuer(c.cfg.JWTIssuer),
+		jwt.WithAudience(c.emailTokenAudience(purpose)),
+		jwt.WithExpirationRequired(),
+	)
+	var claims model.EmailTokenClaims
+	parsed, err := parser.ParseWithClaims(token, &claims, c.keys.Keyfunc)
+	if err != nil || !parsed.Valid || claims.ID == "" {
+		return nil, errInvalidEmailToken
+	}
+	return &claims, nil
+}
+
+// link returns the storefront address that passes the token to the given page.
+func (c *customerUseCaseImpl) link(path string, token string) string {
+	return c.cfg.StoreURL + path + "?" + url.Values{"token": {token}}.Encode()
+}
+
+// emailSendTimeout bounds sending an email after the response has been returned.
+const emailSendTimeout = time.Minute
+
+// inBackground runs send after the request returns, so that responses neither wait for the mail server
+// nor reveal by their timing whether an email was sent. Failures are only logged.
+func (c *customerUseCaseImpl) inBackground(ctx context.Context, what string, send func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), emailSendTimeout)
+	go func() {
+		defer cancel()
+		if err := send(ctx); err != nil {
+			c.logger.Error("can not send email", zap.String("email", what), zap.Error(err))
+		}
+	}()
+}
+
+// throttleEmail limits emails requested for the address and from the client address. Every request counts
+// as a failed attempt, so only a few emails are sent before the lockout.
+func (c *customerUseCaseImpl) throttleEmail(ctx context.Context, email string, clientIP string) error {
+	if err := c.emailThrottle.Check(ctx, email, clientIP); err != nil {
+		return err
+	}
+	return c.emailThrottle.Failure(ctx, email, clientIP)
+}
+
+func (c *customerUseCaseImpl) sendVerificationEmail(ctx context.Context, customer *model.Customer) error {
+	token, expiresAt, err := c.issueEmailToken(ctx, customer, model.EmailTokenVerifyEmail, c.cfg.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return c.mailer.Send(ctx, mailer.Message{
+		To:      customer.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf("Чтобы подтвердить адрес электронной почты, перейдите по ссылке:\n\n%s\n\n"+
+			"Ссылка действует до %s.\n", c.link("/verify-email", token), expiresAt.Format(time.RFC1123)),
+	})
+}
+
+func (c *customerUseCaseImpl) RequestEmailVerification(ctx context.Context, customerID string, clientIP string) error {
+	customer, err := c.customerRepository.GetByID(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if customer.EmailVerifiedAt != nil {
+		return model.ErrEmailAlreadyVerified
+	}
+	if err = c.throttleEmail(ctx, customer.Email, clientIP); err != nil {
+		return err
+	}
+	c.inBackground(ctx, "verification", func(ctx context.Context) error {
+		return c.sendVerificationEmail(ctx, customer)
+	})
+	return nil
+}
+
+func (c *customerUseCaseImpl) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := c.parseEmailToken(token, model.EmailTokenVerifyEmail)
+	if err != nil {
+		return err
+	}
+	ok, err := c.customerRepository.VerifyEmail(ctx, claims.ID, claims.Email)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidEmailToken
+	}
+	return nil
+}
+
+func (c *customerUseCaseImpl) RequestPasswordReset(ctx context.Context, email string, clientIP string) error {
+	email = normalizeEmail(email)
+	if err := c.throttleEmail(ctx, email, clientIP); err != nil {
+		return err
+	}
+	c.inBackground(ctx, "password reset", func(ctx context.Context) error {
+		return c.sendPasswordReset(ctx, email)
+	})
+	return nil
+}
+
+func (c *customerUseCaseImpl) sendPasswordReset(ctx context.Context, email string) error {
+	customer, err := c.customerRepository.GetByEmail(ctx, email)
+	var notFound *model.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, expiresAt, err := c.issueEmailToken(ctx, customer, model.EmailTokenResetPassword, c.cfg.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	err = c.mailer.Send(ctx, mailer.Message{
+		To:      customer.Email,
+		Subject: "Восстановление пароля",
+		Body: fmt.Sprintf("Чтобы задать новый пароль, перейдите по ссылке:\n\n%s\n\n"+
+			"Ссылка действует до %s. Если вы не запрашивали смену пароля, просто проигнорируйте это письмо.\n",
+			c.link("/reset-password", token), expiresAt.Format(time.RFC1123)),
+	})
+	if err != nil {
+		return err
+	}
+	c.logger.Info("password reset requested", zap.String("customer_id", customer.ID))
+	return nil
+}
+
+func (c *customerUseCaseImpl) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	claims, err := c.parseEmailToken(token, model.EmailTokenResetPassword)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	ok, err := c.customerRepository.ResetPassword(ctx, claims.ID, string(hash))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidEmailToken
+	}
+	return c.throttle.Success(ctx, claims.Email)
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ TokenDenylist = (*tokenDenylistImpl)(nil)
+
+// tokenDenylistImpl keeps revoked access tokens in memory, so checking a token does not hit the database.
+// Revocations made by this process are visible immediately, revocations made by other instances
+// after the next Refresh.
+type tokenDenylistImpl struct {
+	logger               *zap.Logger
+	revocationRepository repository.RevocationRepository
+	// tokenTTL is the lifetime of access tokens. Older session revocations can not affect valid tokens.
+	tokenTTL time.Duration
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	sessions map[string]time.Time
+}
+
+func NewTokenDenylist(logger *zap.Logger, revocationRepository repository.RevocationRepository, tokenTTL time.Duration) TokenDenylist {
+	return &tokenDenylistImpl{
+		logger:               logger,
+		revocationRepository: revocationRepository,
+		tokenTTL:             tokenTTL,
+		tokens:               map[string]time.Time{},
+		sessions:             map[string]time.Time{},
+	}
+}
+
+func (d *tokenDenylistImpl) IsRevoked(claims *model.AdminClaims) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, ok := d.tokens[claims.ID]; ok {
+		return true
+	}
+	revokedAt, ok := d.sessions[claims.Subject]
+	// iat has a precision of a second, so tokens issued in the same second as the revocation are revoked too.
+	return ok && claims.IssuedAt != nil && !claims.IssuedAt.After(revokedAt.Truncate(time.Second))
+}
+
+func (d *tokenDenylistImpl) Revoke(ctx context.Context, claims *model.AdminClaims) error {
+	token := &model.RevokedToken{ID: claims.ID, ExpiresAt: claims.ExpiresAt.Time}
+	if err := d.revocationRepository.RevokeToken(ctx, token); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.tokens[token.ID] = token.ExpiresAt
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *tokenDenylistImpl) RevokeSessions(ctx context.Context, adminID string) error {
+	revocation, err := d.revocationRepository.RevokeSessions(ctx, adminID)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.sessions[revocation.Username] = revocation.RevokedAt
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *tokenDenylistImpl) Refresh(ctx context.Context) error {
+	now := time.Now()
+	revokedTokens, err := d.revocationRepository.ListRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+	revocations, err := d.revocationRepository.ListSessionRevocations(ctx, now.Add(-d.tokenTTL))
+	if err != nil {
+		return err
+	}
+
+	tokens := make(map[string]time.Time, len(revokedTokens))
+	for _, token := range revokedTokens {
+		tokens[token.ID] = token.ExpiresAt
+	}
+	sessions := make(map[string]time.Time, len(revocations))
+	for _, revocation := range revocations {
+		sessions[revocation.Username] = revocation.RevokedAt
+	}
+
+	d.mu.Lock()
+	// Keep revocations made while the lists were loading.
+	for id, expiresAt := range d.tokens {
+		if _, ok := tokens[id]; !ok && expiresAt.After(now) {
+			tokens[id] = expiresAt
+		}
+	}
+	for username, revokedAt := range d.sessions {
+		if revokedAt.After(sessions[username]) && revokedAt.After(now.Add(-d.tokenTTL)) {
+			sessions[username] = revokedAt
+		}
+	}
+	d.tokens = tokens
+	d.sessions = sessions
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *tokenDenylistImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Refresh(ctx); err != nil {
+				d.logger.Error("can not refresh token denylist", zap.Error(err))
+			}
+			if err := d.revocationRepository.DeleteExpired(ctx); err != nil {
+				d.logger.Error("can not delete expired revoked tokens", zap.Error(err))
+			}
+		}
+	}
+}
@@ -3,22 +3,194 @@ package usecase
 import (
 	"context"
 	"go_store/internal/model"
+	"net/http"
+	"time"
 )
 
 type AdminUseCase interface {
-	Login(ctx context.Context, username string, password string) (string, error)
+	// EnsureSuperuser creates a superuser from the configuration if there are no administrators yet.
+	EnsureSuperuser(ctx context.Context) error
+	// Login authenticates the administrator. clientIP is used to throttle failed attempts and may be empty.
+	// With two-factor authentication only a challenge for CompleteLogin is returned.
+	Login(ctx context.Context, username string, password string, clientIP string) (*model.AdminTokens, error)
+	// CompleteLogin issues tokens for a login challenge returned by Login, given a one-time code
+	// or a recovery code.
+	CompleteLogin(ctx context.Context, challenge string, code string, recoveryCode string, clientIP string) (*model.AdminTokens, error)
+	// Refresh exchanges a refresh token for new tokens. The used refresh token is revoked.
+	Refresh(ctx context.Context, refreshToken string) (*model.AdminTokens, error)
+	Create(ctx context.Context, username string, password string, roles []model.AdminRole) (string, error)
+	// Disable prevents the administrator from logging in. disabledBy is the username of the caller.
+	Disable(ctx context.Context, id string, disabledBy string) error
+	List(ctx context.Context, limit, offset int32) ([]model.AdminUser, error)
+	ChangePassword(ctx context.Context, username string, currentPassword string, newPassword string) error
+	// Logout revokes the access token and, if given, the refresh token with all tokens rotated from it.
+	Logout(ctx context.Context, claims *model.AdminClaims, refreshToken string) error
+	// RevokeSessions revokes all access and refresh tokens of the administrator.
+	RevokeSessions(ctx context.Context, id string) error
+	// EnrollTOTP starts two-factor enrollment. It is enabled only after ConfirmTOTP.
+	EnrollTOTP(ctx context.Context, username string) (*model.TOTPEnrollment, error)
+	// ConfirmTOTP enables two-factor authentication and returns new recovery codes.
+	// Wrong codes count as failed logins of the username and clientIP.
+	ConfirmTOTP(ctx context.Context, username string, code string, clientIP string) ([]string, error)
+	// DisableTOTP disables two-factor authentication given a one-time code or a recovery code.
+	// Wrong codes count as failed logins of the username and clientIP.
+	DisableTOTP(ctx context.Context, username string, code string, recoveryCode string, clientIP string) error
+	// ResetTOTP disables two-factor authentication of another administrator who lost the device.
+	ResetTOTP(ctx context.Context, id string) error
+	// UnlockLogin removes the login lockout of the username and/or the client address.
+	UnlockLogin(ctx context.Context, username string, clientIP string) error
+}
+
+// CartUseCase manages carts. customerID is the authenticated customer or empty for guests;
+// carts of customers can be used only by them and are reported as not found to anyone else.
+type CartUseCase interface {
+	// Create creates a guest cart, or returns the cart of the customer.
+	Create(ctx context.Context, customerID string) (*model.Cart, error)
+	Get(ctx context.Context, id string, customerID string) (*model.Cart, error)
+	AddItem(ctx context.Context, id string, customerID string, productID string, quantity int32) (*model.Cart, error)
+	UpdateItem(ctx context.Context, id string, customerID string, productID string, quantity int32) (*model.Cart, error)
+	RemoveItem(ctx context.Context, id string, customerID string, productID string) (*model.Cart, error)
+	// Checkout places the order with the items of the cart and empties it.
+	// order holds the details of the checkout, order.CustomerID is the authenticated customer.
+	Checkout(ctx context.Context, id string, order *model.Order) (*model.PlacedOrder, error)
+}
+
+type CustomerUseCase interface {
+	// Register creates an account and sends a verification link to the email.
+	Register(ctx context.Context, email string, password string, name string) (string, error)
+	// Login authenticates the customer by email and password. clientIP is used to throttle failed attempts.
+	// If guestCartID is set, the guest cart is merged into the cart of the customer.
+	Login(ctx context.Context, email string, password string, clientIP string, guestCartID string) (*model.CustomerToken, error)
+	Get(ctx context.Context, id string) (*model.Customer, error)
+	// IsTokenRevoked reports whether the access token was issued before the password was reset or the email changed,
+	// or the customer no longer exists.
+	IsTokenRevoked(ctx context.Context, claims *model.CustomerClaims) (bool, error)
+	// Update changes only the given fields (see model.CustomerField*) and returns the updated customer.
+	// A changed email has to be verified again, and a verification link is sent to it. It revokes the access tokens
+	// issued before, so the customer has to log in again.
+	Update(ctx context.Context, customer *model.Customer, fields []string) (*model.Customer, error)
+	// RequestEmailVerification sends a new verification link to the email of the customer in the background.
+	// Requests are throttled per email and clientIP.
+	RequestEmailVerification(ctx context.Context, customerID string, clientIP string) error
+	VerifyEmail(ctx context.Context, token string) error
+	// RequestPasswordReset sends a password reset link in the background. Nothing is sent if there is no customer
+	// with the email, which the result does not reveal. Requests are throttled per email and clientIP.
+	RequestPasswordReset(ctx context.Context, email string, clientIP string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+}
+
+// LoginThrottle limits login attempts per username and per client address.
+type LoginThrottle interface {
+	// Check returns model.LoginLockedError if the username or the address is locked.
+	Check(ctx context.Context, username, ip string) error
+	Failure(ctx context.Context, username, ip string) error
+	Success(ctx context.Context, username string) error
+	Unlock(ctx context.Context, username, ip string) error
+}
+
+// TokenDenylist tracks access tokens revoked before their expiry.
+type TokenDenylist interface {
+	IsRevoked(claims *model.AdminClaims) bool
+	Revoke(ctx context.Context, claims *model.AdminClaims) error
+	RevokeSessions(ctx context.Context, adminID string) error
+	// Refresh reloads revocations from the database, including ones made by other instances.
+	Refresh(ctx context.Context) error
+	// Run refreshes the denylist and removes expired revocations every interval until ctx is done.
+	Run(ctx context.Context, interval time.Duration)
 }
 
 type ProductUseCase interface {
-	Create(ctx context.Context, name string, description string, price int64) (string, error)
-	Delete(ctx context.Context, id string) error
+	// Create creates the product. An empty tax class is replaced with model.DefaultTaxClass.
+	Create(ctx context.Context, product *model.Product) (string, error)
+	Update(ctx context.Context, product *model.Product, fields []string) (*model.Product, error)
+	Archive(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
 	Get(ctx context.Context, id string) (*model.Product, error)
-	List(ctx context.Context, limit, offset int32) ([]model.Product, error)
+	// List returns a page of products and the cursor of the next page, or nil on the last page.
+	List(ctx context.Context, filter model.ProductFilter, after *model.ProductCursor, limit, offset int32) ([]model.Product, *model.ProductCursor, error)
+	ListArchived(ctx context.Context, limit, offset int32) ([]model.Product, error)
+	Search(ctx context.Context, query string, limit, offset int32) ([]model.ProductSearchResult, error)
+	SetStock(ctx context.Context, id string, stock int32) (int32, error)
+	AdjustStock(ctx context.Context, id string, delta int32) (int32, error)
+}
+
+type CategoryUseCase interface {
+	Create(ctx context.Context, category *model.Category) (string, error)
+	Update(ctx context.Context, category *model.Category, fields []string) (*model.Category, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]model.Category, error)
+	SetProductCategories(ctx context.Context, productID string, categoryIDs []string) error
+}
+
+type CouponUseCase interface {
+	// Create checks that the value fits the type of the coupon and creates it.
+	Create(ctx context.Context, coupon *model.Coupon) (string, error)
+	List(ctx context.Context, limit, offset int32) ([]model.Coupon, error)
+	Disable(ctx context.Context, id string) error
+}
+
+type TaxUseCase interface {
+	CreateRule(ctx context.Context, rule *model.TaxRule) (string, error)
+	// ListRules returns the rules of the country, or of all countries if it is empty.
+	ListRules(ctx context.Context, country string) ([]model.TaxRule, error)
+	DeleteRule(ctx context.Context, id string) error
+}
+
+type ShippingUseCase interface {
+	// CreateMethod checks that the rate table fits the rate type and creates the method.
+	CreateMethod(ctx context.Context, method *model.ShippingMethod) (string, error)
+	ListMethods(ctx context.Context, includeDisabled bool) ([]model.ShippingMethod, error)
+	DisableMethod(ctx context.Context, id string) error
+	// Quote returns the cost of shipping the items to the address with every enabled method
+	// that can deliver them, cheapest first. Only ProductID and Quantity of the items are used.
+	Quote(ctx context.Context, items []model.OrderItem, address model.Address) ([]model.ShippingQuote, error)
+}
+
+type PaymentUseCase interface {
+	// Authorize pays the total of a pending order with the payment method token. customerID and accessToken
+	// are checked as in OrderUseCase.Get. A declined payment is returned with model.PaymentFailed status.
+	// If the provider can not be reached, the payment stays pending and calling Authorize again retries it
+	// under the same ID, which the provider uses to deduplicate requests. A payment pending for longer than
+	// model.PaymentPendingTTL is marked failed and a new one is created.
+	Authorize(ctx context.Context, orderID string, customerID string, accessToken string, token string) (*model.Payment, error)
+	// List returns the payments of the order, newest first.
+	List(ctx context.Context, orderID string) ([]model.Payment, error)
+	// ListForCustomer is List for the customer or the holder of the access token, who can get the order.
+	ListForCustomer(ctx context.Context, orderID string, customerID string, accessToken string) ([]model.Payment, error)
+	// Capture charges the amount of an authorized payment, or the whole authorized amount if it is zero.
+	Capture(ctx context.Context, id string, amount int64) (*model.Payment, error)
+	Void(ctx context.Context, id string) (*model.Payment, error)
+	// Refund returns the amount of a captured payment, or everything not refunded yet if it is zero.
+	Refund(ctx context.Context, id string, amount int64) (*model.Payment, error)
+	// HandleWebhook applies a callback of the payment provider. Repeated and outdated events are ignored.
+	// If an expired payment is authorized or captured after all, the money is released at the provider.
+	// It returns payment.ErrInvalidSignature for callbacks that were not sent by the provider.
+	HandleWebhook(ctx context.Context, header http.Header, body []byte) error
+	// Run marks payments pending for longer than model.PaymentPendingTTL failed every interval until ctx is done.
+	Run(ctx context.Context, interval time.Duration)
 }
 
 type OrderUseCase interface {
-	Create(ctx context.Context, customerName string, customerEmail string, items []model.OrderItem) (string, error)
-	Get(ctx context.Context, id string) (*model.Order, error)
-	UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error
-	List(ctx context.Context, limit, offset int32) ([]model.Order, error)
+	// Create places a new order. order.CustomerID links it to a customer account and may be empty for guests.
+	// order.CouponCode, if set, applies the coupon to the order. Taxes are calculated for order.ShippingAddress.
+	// order.ShippingMethodID, if set, adds the shipping cost; it requires a shipping address.
+	// order.BillingAddress defaults to the shipping address.
+	// If idempotencyKey is set and was already used with the same order, that order is returned with a new
+	// access token instead of placing a new one; with a different order model.AlreadyExistsError is returned.
+	// Keys are scoped to the customer, or to order.CustomerEmail for guests, and expire after
+	// model.IdempotencyKeyTTL.
+	Create(ctx context.Context, order *model.Order, idempotencyKey string) (*model.PlacedOrder, error)
+	// Get returns the order to its customer, customerID being the authenticated customer or empty,
+	// or to anyone with an access token returned when it was placed. Others get model.NotFoundError.
+	Get(ctx context.Context, id string, customerID string, accessToken string) (*model.Order, error)
+	// UpdateStatus moves the order to status. A pending order with a total is processed only once a payment
+	// is authorized or captured, with model.ErrOrderNotPaid otherwise. An order is not cancelled while it has
+	// a pending, authorized or captured payment: model.ErrOrderHasOpenPayment is returned.
+	UpdateStatus(ctx context.Context, id string, status model.OrderStatus, changedBy string, reason string) error
+	History(ctx context.Context, id string) ([]model.OrderStatusChange, error)
+	NextStatuses(ctx context.Context, id string) (model.OrderStatus, []model.OrderStatus, error)
+	// List returns a page of orders and the cursor of the next page, or nil on the last page.
+	List(ctx context.Context, filter model.OrderFilter, sorting model.OrderSort, after *model.OrderCursor, limit, offset int32) ([]model.Order, *model.OrderCursor, error)
+	// Run removes expired idempotency keys every interval until ctx is done.
+	Run(ctx context.Context, interval time.Duration)
 }
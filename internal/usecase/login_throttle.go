@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+	"strings"
+	"time"
+)
+
+// failureMemory is how long a failed login is remembered when no more failures follow.
+const failureMemory = 24 * time.Hour
+
+var _ LoginThrottle = (*loginThrottleImpl)(nil)
+
+// loginThrottleImpl locks a username or a client address after repeated login failures.
+// Keys are prefixed with the scope, so administrators and customers are throttled separately.
+type loginThrottleImpl struct {
+	logger                 *zap.Logger
+	loginAttemptRepository repository.LoginAttemptRepository
+	scope                  string
+	userPolicy             model.LockoutPolicy
+	ipPolicy               model.LockoutPolicy
+}
+
+func NewLoginThrottle(
+	logger *zap.Logger,
+	loginAttemptRepository repository.LoginAttemptRepository,
+	scope string,
+	userPolicy model.LockoutPolicy,
+	ipPolicy model.LockoutPolicy,
+) LoginThrottle {
+	return &loginThrottleImpl{
+		logger:                 logger,
+		loginAttemptRepository: loginAttemptRepository,
+		scope:                  scope,
+		userPolicy:             userPolicy,
+		ipPolicy:               ipPolicy,
+	}
+}
+
+func (l *loginThrottleImpl) userKey(username string) string {
+	return l.scope + ":user:" + strings.ToLower(username)
+}
+
+func (l *loginThrottleImpl) ipKey(ip string) string {
+	return l.scope + ":ip:" + ip
+}
+
+func (l *loginThrottleImpl) keys(username, ip string) []string {
+	var keys []string
+	if username != "" {
+		keys = append(keys, l.userKey(username))
+	}
+	if ip != "" {
+		keys = append(keys, l.ipKey(ip))
+	}
+	return keys
+}
+
+func (l *loginThrottleImpl) Check(ctx context.Context, username, ip string) error {
+	lockedUntil, err := l.loginAttemptRepository.LockedUntil(ctx, l.keys(username, ip))
+	if err != nil {
+		return err
+	}
+	if retryAfter := time.Until(lockedUntil); retryAfter > 0 {
+		return &model.LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (l *loginThrottleImpl) Failure(ctx context.Context, username, ip string) error {
+	resetBefore := time.Now().Add(-failureMemory)
+	lockedUntil, err := l.loginAttemptRepository.RecordFailure(ctx, l.userKey(username), l.userPolicy, resetBefore)
+	if err != nil {
+		return err
+	}
+	if !lockedUntil.IsZero() {
+		l.logger.Warn("login locked for username", zap.String("scope", l.scope),
+			zap.String("username", username), zap.Time("locked_until", lockedUntil))
+	}
+	if ip == "" {
+		return nil
+	}
+	lockedUntil, err = l.loginAttemptRepository.RecordFailure(ctx, l.ipKey(ip), l.ipPolicy, resetBefore)
+	if err != nil {
+		return err
+	}
+	if !lockedUntil.IsZero() {
+		l.logger.Warn("login locked for client address", zap.String("scope", l.scope),
+			zap.String("ip", ip), zap.Time("locked_until", lockedUntil))
+	}
+	return nil
+}
+
+// Success forgets failures of the username. Failures of the address are kept, so that an attacker
+// with one valid account can not reset the counter while guessing other passwords.
+func (l *loginThrottleImpl) Success(ctx context.Context, username string) error {
+	return l.loginAttemptRepository.Reset(ctx, []string{l.userKey(username)})
+}
+
+func (l *loginThrottleImpl) Unlock(ctx context.Context, username, ip string) error {
+	return l.loginAttemptRepository.Reset(ctx, l.keys(username, ip))
+}
@@ -2,45 +2,339 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"go.uber.org/zap"
 	"go_store/internal/model"
 	"go_store/internal/repository"
+	"time"
 )
 
 var _ OrderUseCase = (*orderUseCaseImpl)(nil)
 
 type orderUseCaseImpl struct {
-	logger          *zap.Logger
-	orderRepository repository.OrderRepository
+	logger             *zap.Logger
+	orderRepository    repository.OrderRepository
+	taxRepository      repository.TaxRepository
+	shippingRepository repository.ShippingRepository
 }
 
-func NewOrderUseCase(logger *zap.Logger, orderRepository repository.OrderRepository) OrderUseCase {
+func NewOrderUseCase(logger *zap.Logger, orderRepository repository.OrderRepository, taxRepository repository.TaxRepository,
+	shippingRepository repository.ShippingRepository) OrderUseCase {
 	return &orderUseCaseImpl{
-		logger:          logger,
-		orderRepository: orderRepository,
+		logger:             logger,
+		orderRepository:    orderRepository,
+		taxRepository:      taxRepository,
+		shippingRepository: shippingRepository,
 	}
 }
 
-func (o *orderUseCaseImpl) Create(ctx context.Context, customerName string, customerEmail string, items []model.OrderItem) (string, error) {
-	return o.orderRepository.Create(ctx, &model.Order{
-		CustomerName:  customerName,
-		CustomerEmail: customerEmail,
-		Items:         items,
-		Status:        model.UNSPECIFIED,
-	})
+func (o *orderUseCaseImpl) Create(ctx context.Context, order *model.Order, idempotencyKey string) (*model.PlacedOrder, error) {
+	order.Status = model.PENDING
+	products := make(map[string]bool, len(order.Items))
+	for _, item := range order.Items {
+		if products[item.ProductID] {
+			return nil, &model.InvalidArgumentError{Field: "items.product_id", Description: "must not repeat: " + item.ProductID}
+		}
+		products[item.ProductID] = true
+	}
+	if order.ShippingAddress != nil {
+		if err := order.ShippingAddress.CheckComplete("shipping_address"); err != nil {
+			return nil, err
+		}
+		if order.BillingAddress == nil {
+			billing := *order.ShippingAddress
+			order.BillingAddress = &billing
+		}
+	}
+	if order.BillingAddress != nil {
+		if err := order.BillingAddress.CheckComplete("billing_address"); err != nil {
+			return nil, err
+		}
+	}
+
+	finalize, err := o.finalizer(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if idempotencyKey == "" {
+		id, err := o.orderRepository.Create(ctx, order, hashToken(accessToken), nil, finalize)
+		if err != nil {
+			return nil, err
+		}
+		return &model.PlacedOrder{ID: id, AccessToken: accessToken}, nil
+	}
+
+	hash, err := orderRequestHash(order)
+	if err != nil {
+		return nil, err
+	}
+	key := &model.IdempotencyKey{Scope: idempotencyScope(order), Key: idempotencyKey, RequestHash: hash}
+	if placed, err := o.replay(ctx, key); err != nil || placed != nil {
+		return placed, err
+	}
+
+	id, err := o.orderRepository.Create(ctx, order, hashToken(accessToken), key, finalize)
+	var exists *model.AlreadyExistsError
+	if errors.As(err, &exists) {
+		// A concurrent request with the same key has placed the order first.
+		if placed, err := o.replay(ctx, key); err != nil || placed != nil {
+			return placed, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model.PlacedOrder{ID: id, AccessToken: accessToken}, nil
+}
+
+// replay returns the order created with the key, if any, with a new access token: the token returned
+// to the first request can not be recovered, since only its hash is stored.
+// It fails if the key was used for a request with another payload.
+func (o *orderUseCaseImpl) replay(ctx context.Context, key *model.IdempotencyKey) (*model.PlacedOrder, error) {
+	stored, err := o.orderRepository.GetIdempotencyKey(ctx, key.Scope, key.Key)
+	var notFound *model.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.RequestHash != key.RequestHash {
+		o.logger.Warn("idempotency key reused with another request", zap.String("order_id", stored.OrderID))
+		return nil, &model.AlreadyExistsError{Entity: "idempotency key"}
+	}
+
+	accessToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err = o.orderRepository.AddAccessToken(ctx, stored.OrderID, hashToken(accessToken)); err != nil {
+		return nil, err
+	}
+	return &model.PlacedOrder{ID: stored.OrderID, AccessToken: accessToken}, nil
 }
 
-func (o *orderUseCaseImpl) Get(ctx context.Context, id string) (*model.Order, error) {
-	return o.orderRepository.GetByID(ctx, id)
+// idempotencyScope returns the scope of idempotency keys sent with the order, so that keys of different
+// clients never collide: the customer, or the email of a guest.
+func idempotencyScope(order *model.Order) string {
+	if order.CustomerID != "" {
+		return "customer:" + order.CustomerID
+	}
+	return "guest:" + normalizeEmail(order.CustomerEmail)
 }
 
-func (o *orderUseCaseImpl) UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error {
-	return o.orderRepository.UpdateStatus(ctx, &model.Order{
-		ID:     id,
-		Status: status,
-	})
+func (o *orderUseCaseImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := o.orderRepository.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+				o.logger.Error("can not delete expired idempotency keys", zap.Error(err))
+			}
+		}
+	}
 }
 
-func (o *orderUseCaseImpl) List(ctx context.Context, limit, offset int32) ([]model.Order, error) {
-	return o.orderRepository.List(ctx, limit, offset)
+// finalizer returns the function adding shipping and taxes to the order once its items are priced.
+// Taxes are calculated for the shipping address, or for the billing address if there is nothing to ship.
+// It also checks that orders with items to ship have a shipping address and method, since the weights
+// of the items are known only then.
+func (o *orderUseCaseImpl) finalizer(ctx context.Context, order *model.Order) (func(order *model.Order) error, error) {
+	if order.ShippingAddress == nil && order.ShippingMethodID != "" {
+		return nil, &model.InvalidArgumentError{Field: "shipping_address", Description: "is required with shipping_method_id"}
+	}
+
+	var method *model.ShippingMethod
+	if order.ShippingMethodID != "" {
+		var err error
+		method, err = o.shippingRepository.GetMethod(ctx, order.ShippingMethodID)
+		var notFound *model.NotFoundError
+		if errors.As(err, &notFound) {
+			return nil, &model.InvalidReferenceError{Field: "shipping_method_id", Entity: "shipping method", ID: order.ShippingMethodID}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	taxAddress := order.ShippingAddress
+	if taxAddress == nil {
+		taxAddress = order.BillingAddress
+	}
+	var taxes *taxCalculator
+	if taxAddress != nil {
+		rules, err := o.taxRepository.ListRules(ctx, taxAddress.Country)
+		if err != nil {
+			return nil, err
+		}
+		taxes = newTaxCalculator(*taxAddress, rules)
+	}
+
+	return func(order *model.Order) error {
+		if order.Weight() > 0 {
+			if order.ShippingAddress == nil {
+				return &model.InvalidArgumentError{Field: "shipping_address", Description: "is required for orders with items to ship"}
+			}
+			if method == nil {
+				return &model.InvalidArgumentError{Field: "shipping_method_id", Description: "is required for orders with items to ship"}
+			}
+		}
+		if method != nil {
+			cost, ok := method.Cost(order.ShippingAddress.Country, order.Subtotal-order.Discount, order.Weight())
+			if !ok {
+				return model.ErrShippingUnavailable
+			}
+			order.ShippingMethodName = method.Name
+			order.ShippingCost = cost
+		}
+		if taxes == nil {
+			return nil
+		}
+		return taxes.Apply(order)
+	}, nil
+}
+
+// orderRequestHash identifies what the client asked for, before prices and status are filled in.
+func orderRequestHash(order *model.Order) (string, error) {
+	type requestItem struct {
+		ProductID string `json:"product_id"`
+		Quantity  int32  `json:"quantity"`
+	}
+	request := struct {
+		CustomerID       string         `json:"customer_id"`
+		CustomerName     string         `json:"customer_name"`
+		CustomerEmail    string         `json:"customer_email"`
+		Items            []requestItem  `json:"items"`
+		CouponCode       string         `json:"coupon_code"`
+		ShippingAddress  *model.Address `json:"shipping_address"`
+		BillingAddress   *model.Address `json:"billing_address"`
+		ShippingMethodID string         `json:"shipping_method_id"`
+	}{
+		CustomerID:       order.CustomerID,
+		CustomerName:     order.CustomerName,
+		CustomerEmail:    order.CustomerEmail,
+		Items:            make([]requestItem, 0, len(order.Items)),
+		CouponCode:       order.CouponCode,
+		ShippingAddress:  order.ShippingAddress,
+		BillingAddress:   order.BillingAddress,
+		ShippingMethodID: order.ShippingMethodID,
+	}
+	for _, item := range order.Items {
+		request.Items = append(request.Items, requestItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (o *orderUseCaseImpl) Get(ctx context.Context, id string, customerID string, accessToken string) (*model.Order, error) {
+	order, err := o.orderRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.CustomerID != "" && order.CustomerID == customerID {
+		return order, nil
+	}
+	if accessToken != "" {
+		ok, err := o.orderRepository.HasAccessToken(ctx, id, hashToken(accessToken))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return order, nil
+		}
+	}
+	return nil, &model.NotFoundError{Entity: "order", ID: id}
+}
+
+func (o *orderUseCaseImpl) UpdateStatus(ctx context.Context, id string, status model.OrderStatus, changedBy string, reason string) error {
+	order, err := o.orderRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !order.Status.CanTransitionTo(status) {
+		return &model.InvalidTransitionError{OrderID: id, From: order.Status, To: status}
+	}
+
+	return o.orderRepository.UpdateStatus(ctx, id, &model.OrderStatusChange{
+		OldStatus: order.Status,
+		NewStatus: status,
+		ChangedBy: changedBy,
+		Reason:    reason,
+	}, paymentCheck(order, status))
+}
+
+// paymentCheck returns the check that the payments of the order allow moving it to status, or nil if
+// they do not matter: an order is processed only once its total is authorized or captured, and is cancelled
+// only when no money is held or charged.
+func paymentCheck(order *model.Order, status model.OrderStatus) func(payments []model.Payment) error {
+	switch {
+	case status == model.CANCELLED:
+		return func(payments []model.Payment) error {
+			for _, payment := range payments {
+				switch payment.Status {
+				case model.PaymentPending, model.PaymentAuthorized, model.PaymentCaptured:
+					return model.ErrOrderHasOpenPayment
+				}
+			}
+			return nil
+		}
+	case status == model.PROCESSING && order.Status == model.PENDING && order.Total > 0:
+		return func(payments []model.Payment) error {
+			for _, payment := range payments {
+				if payment.Status == model.PaymentAuthorized || payment.Status == model.PaymentCaptured {
+					return nil
+				}
+			}
+			return model.ErrOrderNotPaid
+		}
+	default:
+		return nil
+	}
+}
+
+func (o *orderUseCaseImpl) History(ctx context.Context, id string) ([]model.OrderStatusChange, error) {
+	return o.orderRepository.GetHistory(ctx, id)
+}
+
+func (o *orderUseCaseImpl) NextStatuses(ctx context.Context, id string) (model.OrderStatus, []model.OrderStatus, error) {
+	order, err := o.orderRepository.GetByID(ctx, id)
+	if err != nil {
+		return model.UNSPECIFIED, nil, err
+	}
+	return order.Status, order.Status.NextStatuses(), nil
+}
+
+func (o *orderUseCaseImpl) List(ctx context.Context, filter model.OrderFilter, sorting model.OrderSort, after *model.OrderCursor, limit, offset int32) ([]model.Order, *model.OrderCursor, error) {
+	if after != nil && after.Sort != sorting {
+		return nil, nil, model.ErrPageTokenMismatch
+	}
+
+	limit = pageSize(limit)
+	orders, err := o.orderRepository.List(ctx, filter, sorting, after, limit+1, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(orders) <= int(limit) {
+		return orders, nil, nil
+	}
+
+	orders = orders[:limit]
+	last := orders[len(orders)-1]
+	return orders, last.Cursor(sorting), nil
 }
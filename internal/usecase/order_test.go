@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+	"testing"
+)
+
+// stubTaxRepository returns the rules of the requested country.
+type stubTaxRepository struct {
+	repository.TaxRepository
+	rules []model.TaxRule
+}
+
+func (s *stubTaxRepository) ListRules(_ context.Context, country string) ([]model.TaxRule, error) {
+	var rules []model.TaxRule
+	for _, rule := range s.rules {
+		if rule.Country == country {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// stubShippingRepository knows a single method.
+type stubShippingRepository struct {
+	repository.ShippingRepository
+	method model.ShippingMethod
+}
+
+func (s *stubShippingRepository) GetMethod(_ context.Context, id string) (*model.ShippingMethod, error) {
+	if id != s.method.ID {
+		return nil, &model.NotFoundError{Entity: "shipping method", ID: id}
+	}
+	method := s.method
+	return &method, nil
+}
+
+func newTestOrderUseCase() *orderUseCaseImpl {
+	return &orderUseCaseImpl{
+		taxRepository: &stubTaxRepository{rules: []model.TaxRule{
+			{Country: "DE", TaxClass: "standard", Name: "VAT", Rate: 1900},
+			{Country: "FR", TaxClass: "standard", Name: "TVA", Rate: 2000},
+		}},
+		shippingRepository: &stubShippingRepository{method: model.ShippingMethod{
+			ID: "flat", Name: "Flat", RateType: model.ShippingRateFlat, FlatRate: 500,
+		}},
+	}
+}
+
+func TestOrderFinalizer(t *testing.T) {
+	de := &model.Address{Country: "DE"}
+	fr := &model.Address{Country: "FR"}
+	tests := []struct {
+		name         string
+		shipping     *model.Address
+		billing      *model.Address
+		methodID     string
+		weight       int32
+		wantCountry  string
+		wantTax      int64
+		wantShipping int64
+		wantErr      bool
+	}{
+		{"taxed by the shipping address", de, fr, "flat", 100, "DE", 190, 500, false},
+		{"taxed by the billing address without shipping", nil, fr, "", 0, "FR", 200, 0, false},
+		{"no address", nil, nil, "", 0, "", 0, 0, false},
+		{"items to ship without a shipping address", nil, fr, "", 100, "", 0, 0, true},
+		{"items to ship without a shipping method", de, nil, "", 100, "", 0, 0, true},
+		{"method charged for an order with nothing to ship", de, nil, "flat", 0, "DE", 190, 500, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &model.Order{
+				Items:            []model.OrderItem{{ProductID: "p1", Quantity: 1, UnitPrice: 1000, TaxClass: "standard", UnitWeight: tt.weight}},
+				ShippingAddress:  tt.shipping,
+				BillingAddress:   tt.billing,
+				ShippingMethodID: tt.methodID,
+			}
+			order.CalculateTotals()
+
+			finalize, err := newTestOrderUseCase().finalizer(context.Background(), order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = finalize(order)
+			if tt.wantErr {
+				var invalid *model.InvalidArgumentError
+				if !errors.As(err, &invalid) {
+					t.Fatalf("finalize error = %v, want %T", err, invalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.TaxCountry != tt.wantCountry || order.Tax != tt.wantTax || order.ShippingCost != tt.wantShipping {
+				t.Errorf("tax country %q, tax %d, shipping %d, want %q, %d, %d",
+					order.TaxCountry, order.Tax, order.ShippingCost, tt.wantCountry, tt.wantTax, tt.wantShipping)
+			}
+			if want := 1000 + tt.wantShipping + tt.wantTax; order.Total != want {
+				t.Errorf("total = %d, want %d", order.Total, want)
+			}
+		})
+	}
+}
+
+func TestPaymentCheck(t *testing.T) {
+	pending := &model.Order{Status: model.PENDING, Total: 1000}
+	tests := []struct {
+		name      string
+		order     *model.Order
+		status    model.OrderStatus
+		payments  []model.PaymentStatus
+		wantCheck bool
+		wantErr   error
+	}{
+		{"processing an authorized order", pending, model.PROCESSING, []model.PaymentStatus{model.PaymentFailed, model.PaymentAuthorized}, true, nil},
+		{"processing a captured order", pending, model.PROCESSING, []model.PaymentStatus{model.PaymentCaptured}, true, nil},
+		{"processing an unpaid order", pending, model.PROCESSING, []model.PaymentStatus{model.PaymentPending}, true, model.ErrOrderNotPaid},
+		{"processing an order with nothing to pay", &model.Order{Status: model.PENDING}, model.PROCESSING, nil, false, nil},
+		{"cancelling an order with a pending payment", pending, model.CANCELLED, []model.PaymentStatus{model.PaymentPending}, true, model.ErrOrderHasOpenPayment},
+		{"cancelling an order with a captured payment", pending, model.CANCELLED, []model.PaymentStatus{model.PaymentCaptured}, true, model.ErrOrderHasOpenPayment},
+		{"cancelling an order with closed payments", pending, model.CANCELLED, []model.PaymentStatus{model.PaymentVoided, model.PaymentRefunded}, true, nil},
+		{"completing an order", &model.Order{Status: model.PROCESSING, Total: 1000}, model.COMPLETED, nil, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := paymentCheck(tt.order, tt.status)
+			if (check != nil) != tt.wantCheck {
+				t.Fatalf("check returned: %v, want %v", check != nil, tt.wantCheck)
+			}
+			if check == nil {
+				return
+			}
+			payments := make([]model.Payment, len(tt.payments))
+			for i, status := range tt.payments {
+				payments[i].Status = status
+			}
+			if err := check(payments); !errors.Is(err, tt.wantErr) {
+				t.Errorf("check error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package usecase
+
+const defaultPageSize = 20
+
+// pageSize returns the number of items to return when a client asks for limit items.
+func pageSize(limit int32) int32 {
+	if limit == 0 {
+		return defaultPageSize
+	}
+	return limit
+}
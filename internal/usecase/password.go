@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"sync"
+)
+
+// dummyPasswordHash is a hash of a password nobody knows, with the cost used for real passwords.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// compareDummyPassword spends as much time as checking a real password, so that a login with an unknown
+// name can not be told from a wrong password by the response time.
+func compareDummyPassword(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+}
@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/payment"
+	"go_store/internal/repository"
+	"net/http"
+	"time"
+)
+
+var _ PaymentUseCase = (*paymentUseCaseImpl)(nil)
+
+type paymentUseCaseImpl struct {
+	logger            *zap.Logger
+	paymentRepository repository.PaymentRepository
+	orderUseCase      OrderUseCase
+	provider          payment.PaymentProvider
+}
+
+func NewPaymentUseCase(logger *zap.Logger, paymentRepository repository.PaymentRepository, orderUseCase OrderUseCase,
+	provider payment.PaymentProvider) PaymentUseCase {
+	return &paymentUseCaseImpl{
+		logger:            logger,
+		paymentRepository: paymentRepository,
+		orderUseCase:      orderUseCase,
+		provider:          provider,
+	}
+}
+
+func (p *paymentUseCaseImpl) Authorize(ctx context.Context, orderID string, customerID string, accessToken string,
+	token string) (*model.Payment, error) {
+	order, err := p.orderUseCase.Get(ctx, orderID, customerID, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.PENDING || order.Total <= 0 {
+		return nil, model.ErrOrderNotPayable
+	}
+
+	pending, err := p.pendingPayment(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		id, err := p.paymentRepository.Create(ctx, &model.Payment{
+			OrderID:  order.ID,
+			Provider: p.provider.Name(),
+			Status:   model.PaymentPending,
+			Amount:   order.Total,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if pending, err = p.paymentRepository.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	id := pending.ID
+
+	result, err := p.provider.Authorize(ctx, payment.AuthorizeRequest{
+		PaymentID: id,
+		OrderID:   order.ID,
+		Amount:    pending.Amount,
+		Token:     token,
+	})
+	if err != nil {
+		// The provider may have authorized the payment before the error, so it stays pending:
+		// a retry sends the same payment ID and gets the outcome of the first request.
+		p.logger.Warn("payment provider error, payment left pending", zap.String("payment_id", id), zap.Error(err))
+		return nil, err
+	}
+	if result.Status != model.PaymentPending && !pending.Status.CanTransitionTo(result.Status) {
+		return nil, fmt.Errorf("payment provider returned status %s for authorization", result.Status)
+	}
+
+	authorized := *pending
+	authorized.Reference = result.Reference
+	authorized.Status = result.Status
+	authorized.FailureReason = result.FailureReason
+	err = p.paymentRepository.Update(ctx, &authorized, pending)
+	if errors.Is(err, model.ErrPaymentChanged) {
+		// A callback of the provider has already completed the payment.
+		return p.paymentRepository.GetByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.logger.Info("payment authorized", zap.String("payment_id", id), zap.String("order_id", order.ID),
+		zap.String("status", string(authorized.Status)))
+	return &authorized, nil
+}
+
+// pendingPayment returns the pending payment of the order to be retried, or nil if there is none.
+// A payment pending for longer than model.PaymentPendingTTL is marked failed instead.
+func (p *paymentUseCaseImpl) pendingPayment(ctx context.Context, orderID string) (*model.Payment, error) {
+	payments, err := p.paymentRepository.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range payments {
+		current := &payments[i]
+		if current.Status != model.PaymentPending || current.Provider != p.provider.Name() {
+			continue
+		}
+		if time.Since(current.CreatedAt) < model.PaymentPendingTTL {
+			return current, nil
+		}
+
+		expired := *current
+		expired.Status = model.PaymentFailed
+		expired.FailureReason = model.PaymentExpiredReason
+		if err = p.paymentRepository.Update(ctx, &expired, current); err != nil {
+			return nil, err
+		}
+		p.logger.Info("pending payment expired", zap.String("payment_id", current.ID))
+	}
+	return nil, nil
+}
+
+func (p *paymentUseCaseImpl) List(ctx context.Context, orderID string) ([]model.Payment, error) {
+	return p.paymentRepository.ListByOrder(ctx, orderID)
+}
+
+func (p *paymentUseCaseImpl) ListForCustomer(ctx context.Context, orderID string, customerID string, accessToken string) ([]model.Payment, error) {
+	if _, err := p.orderUseCase.Get(ctx, orderID, customerID, accessToken); err != nil {
+		return nil, err
+	}
+	return p.paymentRepository.ListByOrder(ctx, orderID)
+}
+
+func (p *paymentUseCaseImpl) Capture(ctx context.Context, id string, amount int64) (*model.Payment, error) {
+	current, err := p.paymentRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != model.PaymentAuthorized {
+		return nil, &model.InvalidPaymentTransitionError{PaymentID: id, From: current.Status, To: model.PaymentCaptured}
+	}
+	if amount == 0 {
+		amount = current.Amount
+	}
+	if amount > current.Amount {
+		return nil, &model.InvalidArgumentError{Field: "amount", Description: "must not exceed the authorized amount"}
+	}
+
+	if err = p.provider.Capture(ctx, current.Reference, amount); err != nil {
+		return nil, err
+	}
+	captured := *current
+	captured.Status = model.PaymentCaptured
+	captured.CapturedAmount = amount
+	return p.save(ctx, &captured, current)
+}
+
+func (p *paymentUseCaseImpl) Void(ctx context.Context, id string) (*model.Payment, error) {
+	current, err := p.paymentRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !current.Status.CanTransitionTo(model.PaymentVoided) {
+		return nil, &model.InvalidPaymentTransitionError{PaymentID: id, From: current.Status, To: model.PaymentVoided}
+	}
+
+	if err = p.provider.Void(ctx, current.Reference); err != nil {
+		return nil, err
+	}
+	voided := *current
+	voided.Status = model.PaymentVoided
+	return p.save(ctx, &voided, current)
+}
+
+func (p *paymentUseCaseImpl) Refund(ctx context.Context, id string, amount int64) (*model.Payment, error) {
+	current, err := p.paymentRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != model.PaymentCaptured {
+		return nil, &model.InvalidPaymentTransitionError{PaymentID: id, From: current.Status, To: model.PaymentRefunded}
+	}
+	remaining := current.CapturedAmount - current.RefundedAmount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return nil, &model.InvalidArgumentError{Field: "amount", Description: "must not exceed the captured amount that was not refunded"}
+	}
+
+	if err = p.provider.Refund(ctx, current.Reference, amount); err != nil {
+		return nil, err
+	}
+	refunded := *current
+	refunded.RefundedAmount += amount
+	if refunded.RefundedAmount == refunded.CapturedAmount {
+		refunded.Status = model.PaymentRefunded
+	}
+	return p.save(ctx, &refunded, current)
+}
+
+// save stores a payment changed at the provider.
+func (p *paymentUseCaseImpl) save(ctx context.Context, payment *model.Payment, previous *model.Payment) (*model.Payment, error) {
+	if err := p.paymentRepository.Update(ctx, payment, previous); err != nil {
+		// The provider has already moved the money, so the mismatch has to be resolved by hand.
+		p.logger.Error("payment changed at the provider but not saved",
+			zap.String("payment_id", payment.ID), zap.String("status", string(payment.Status)), zap.Error(err))
+		return nil, err
+	}
+	p.logger.Info("payment updated", zap.String("payment_id", payment.ID), zap.String("status", string(payment.Status)))
+	return payment, nil
+}
+
+func (p *paymentUseCaseImpl) HandleWebhook(ctx context.Context, header http.Header, body []byte) error {
+	event, err := p.provider.ParseWebhook(header, body)
+	if err != nil {
+		return err
+	}
+
+	current, err := p.paymentRepository.GetByID(ctx, event.PaymentID)
+	if err != nil {
+		return err
+	}
+	if current.Provider != p.provider.Name() {
+		return &model.NotFoundError{Entity: "payment", ID: event.PaymentID}
+	}
+	if current.Status == event.Status {
+		return nil
+	}
+	if current.Status == model.PaymentFailed && current.FailureReason == model.PaymentExpiredReason {
+		return p.releaseExpired(ctx, current, event)
+	}
+	if !current.Status.CanTransitionTo(event.Status) {
+		p.logger.Info("outdated payment event ignored", zap.String("payment_id", current.ID),
+			zap.String("status", string(current.Status)), zap.String("event_status", string(event.Status)))
+		return nil
+	}
+
+	updated := *current
+	updated.Status = event.Status
+	updated.FailureReason = event.FailureReason
+	if updated.Reference == "" {
+		updated.Reference = event.Reference
+	}
+	if event.Status == model.PaymentCaptured {
+		updated.CapturedAmount = updated.Amount
+	}
+	if err = p.paymentRepository.Update(ctx, &updated, current); err != nil {
+		return err
+	}
+	p.logger.Info("payment updated by provider", zap.String("payment_id", updated.ID), zap.String("status", string(updated.Status)))
+	return nil
+}
+
+// releaseExpired gives back the money of a payment that the provider completed after it had expired:
+// the order may have been paid again in the meantime.
+func (p *paymentUseCaseImpl) releaseExpired(ctx context.Context, expired *model.Payment, event *payment.Event) error {
+	var err error
+	switch event.Status {
+	case model.PaymentAuthorized:
+		err = p.provider.Void(ctx, event.Reference)
+	case model.PaymentCaptured:
+		err = p.provider.Refund(ctx, event.Reference, expired.Amount)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p.logger.Warn("expired payment completed by provider, money released", zap.String("payment_id", expired.ID),
+		zap.String("event_status", string(event.Status)))
+	return nil
+}
+
+func (p *paymentUseCaseImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := p.paymentRepository.ExpirePending(ctx)
+			if err != nil {
+				p.logger.Error("can not expire pending payments", zap.Error(err))
+			} else if expired > 0 {
+				p.logger.Info("pending payments expired", zap.Int64("count", expired))
+			}
+		}
+	}
+}
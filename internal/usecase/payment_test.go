@@ -0,0 +1,427 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/payment"
+	"go_store/internal/repository"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+// memoryPaymentRepository keeps payments in memory like the database does, including the single active
+// payment per order and the optimistic Update.
+type memoryPaymentRepository struct {
+	mu       sync.Mutex
+	payments []*model.Payment
+}
+
+var _ repository.PaymentRepository = (*memoryPaymentRepository)(nil)
+
+func (m *memoryPaymentRepository) Create(_ context.Context, p *model.Payment) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.payments {
+		if existing.OrderID == p.OrderID && existing.Status != model.PaymentFailed && existing.Status != model.PaymentVoided {
+			return "", model.ErrOrderAlreadyPaid
+		}
+	}
+	created := *p
+	created.ID = fmt.Sprintf("payment-%d", len(m.payments)+1)
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = created.CreatedAt
+	m.payments = append(m.payments, &created)
+	return created.ID, nil
+}
+
+func (m *memoryPaymentRepository) GetByID(_ context.Context, id string) (*model.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.payments {
+		if p.ID == id {
+			found := *p
+			return &found, nil
+		}
+	}
+	return nil, &model.NotFoundError{Entity: "payment", ID: id}
+}
+
+func (m *memoryPaymentRepository) ListByOrder(_ context.Context, orderID string) ([]model.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	payments := []model.Payment{}
+	for _, p := range slices.Backward(m.payments) {
+		if p.OrderID == orderID {
+			payments = append(payments, *p)
+		}
+	}
+	return payments, nil
+}
+
+func (m *memoryPaymentRepository) Update(_ context.Context, p *model.Payment, previous *model.Payment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, stored := range m.payments {
+		if stored.ID != p.ID {
+			continue
+		}
+		if stored.Status != previous.Status || stored.CapturedAmount != previous.CapturedAmount ||
+			stored.RefundedAmount != previous.RefundedAmount {
+			return model.ErrPaymentChanged
+		}
+		stored.Reference = p.Reference
+		stored.Status = p.Status
+		stored.CapturedAmount = p.CapturedAmount
+		stored.RefundedAmount = p.RefundedAmount
+		stored.FailureReason = p.FailureReason
+		stored.UpdatedAt = time.Now()
+		p.UpdatedAt = stored.UpdatedAt
+		return nil
+	}
+	return model.ErrPaymentChanged
+}
+
+func (m *memoryPaymentRepository) ExpirePending(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expired int64
+	for _, p := range m.payments {
+		if p.Status == model.PaymentPending && time.Since(p.CreatedAt) >= model.PaymentPendingTTL {
+			p.Status = model.PaymentFailed
+			p.FailureReason = model.PaymentExpiredReason
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// age moves the creation time of the payment back by d.
+func (m *memoryPaymentRepository) age(id string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.payments {
+		if p.ID == id {
+			p.CreatedAt = p.CreatedAt.Add(-d)
+		}
+	}
+}
+
+// stubOrderUseCase returns its orders to anyone. Only Get is implemented.
+type stubOrderUseCase struct {
+	OrderUseCase
+	orders map[string]*model.Order
+}
+
+func (s *stubOrderUseCase) Get(_ context.Context, id string, _ string, _ string) (*model.Order, error) {
+	order, ok := s.orders[id]
+	if !ok {
+		return nil, &model.NotFoundError{Entity: "order", ID: id}
+	}
+	return order, nil
+}
+
+func newTestPaymentUseCase() (*paymentUseCaseImpl, *memoryPaymentRepository, *payment.Fake) {
+	repo := &memoryPaymentRepository{}
+	fake := payment.NewFake(testWebhookSecret)
+	orders := &stubOrderUseCase{orders: map[string]*model.Order{
+		"order-1": {ID: "order-1", Status: model.PENDING, Total: 1000},
+		"order-2": {ID: "order-2", Status: model.PROCESSING, Total: 1000},
+		"order-3": {ID: "order-3", Status: model.PENDING},
+	}}
+	return &paymentUseCaseImpl{logger: zap.NewNop(), paymentRepository: repo, orderUseCase: orders, provider: fake}, repo, fake
+}
+
+func checkPayment(t *testing.T, p *model.Payment, status model.PaymentStatus, captured int64, refunded int64) {
+	t.Helper()
+	if p.Status != status || p.CapturedAmount != captured || p.RefundedAmount != refunded {
+		t.Errorf("payment is %s, captured %d, refunded %d, want %s, %d, %d",
+			p.Status, p.CapturedAmount, p.RefundedAmount, status, captured, refunded)
+	}
+}
+
+func TestPaymentAuthorizeCaptureRefund(t *testing.T) {
+	ctx := context.Background()
+	p, repo, _ := newTestPaymentUseCase()
+
+	authorized, err := p.Authorize(ctx, "order-1", "", "", "tok_visa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPayment(t, authorized, model.PaymentAuthorized, 0, 0)
+	if authorized.Amount != 1000 || authorized.Reference != "fake_"+authorized.ID {
+		t.Errorf("amount %d, reference %q", authorized.Amount, authorized.Reference)
+	}
+	if _, err = p.Authorize(ctx, "order-1", "", "", "tok_visa"); !errors.Is(err, model.ErrOrderAlreadyPaid) {
+		t.Errorf("second Authorize error = %v, want %v", err, model.ErrOrderAlreadyPaid)
+	}
+
+	var invalidArgument *model.InvalidArgumentError
+	if _, err = p.Capture(ctx, authorized.ID, 1001); !errors.As(err, &invalidArgument) {
+		t.Errorf("Capture over the authorized amount error = %v, want InvalidArgumentError", err)
+	}
+	captured, err := p.Capture(ctx, authorized.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPayment(t, captured, model.PaymentCaptured, 1000, 0)
+
+	refunded, err := p.Refund(ctx, authorized.ID, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPayment(t, refunded, model.PaymentCaptured, 1000, 300)
+	if _, err = p.Refund(ctx, authorized.ID, 701); !errors.As(err, &invalidArgument) {
+		t.Errorf("Refund over the remaining amount error = %v, want InvalidArgumentError", err)
+	}
+
+	refunded, err = p.Refund(ctx, authorized.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPayment(t, refunded, model.PaymentRefunded, 1000, 1000)
+
+	var invalidTransition *model.InvalidPaymentTransitionError
+	if _, err = p.Refund(ctx, authorized.ID, 1); !errors.As(err, &invalidTransition) {
+		t.Errorf("Refund of a refunded payment error = %v, want InvalidPaymentTransitionError", err)
+	}
+
+	stored, err := repo.GetByID(ctx, authorized.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPayment(t, stored, model.PaymentRefunded, 1000, 1000)
+}
+
+func TestPaymentAuthorizeRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		orderID string
+		wantErr error
+	}{
+		{"order not pending", "order-2", model.ErrOrderNotPayable},
+		{"nothing to pay", "order-3", model.ErrOrderNotPayable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, _ := newTestPaymentUseCase()
+			if _, err := p.Authorize(context.Background(), tt.orderID, "", "", "tok_visa"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Authorize error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPaymentAuthorizeDeclined(t *testing.T) {
+	ctx := context.Background()
+	p, _, _ := newTestPaymentUseCase()
+
+	declined, err := p.Authorize(ctx, "order-1", "", "", payment.FakeTokenDecline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if declined.Status != model.PaymentFailed || declined.FailureReason != "card declined" {
+		t.Errorf("payment is %s with reason %q, want declined", declined.Status, declined.FailureReason)
+	}
+
+	// A declined payment does not keep the order from being paid.
+	authorized, err := p.Authorize(ctx, "order-1", "", "", "tok_visa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorized.ID == declined.ID || authorized.Status != model.PaymentAuthorized {
+		t.Errorf("payment %s is %s, want a new authorized payment", authorized.ID, authorized.Status)
+	}
+}
+
+func TestPaymentAuthorizeProviderError(t *testing.T) {
+	ctx := context.Background()
+	p, repo, _ := newTestPaymentUseCase()
+
+	if _, err := p.Authorize(ctx, "order-1", "", "", payment.FakeTokenError); err == nil {
+		t.Fatal("Authorize with an unreachable provider succeeded")
+	}
+	payments, _ := repo.ListByOrder(ctx, "order-1")
+	if len(payments) != 1 || payments[0].Status != model.PaymentPending {
+		t.Fatalf("payments = %+v, want one pending payment", payments)
+	}
+
+	// The retry sends the same payment to the provider instead of creating another one.
+	authorized, err := p.Authorize(ctx, "order-1", "", "", "tok_visa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorized.ID != payments[0].ID || authorized.Status != model.PaymentAuthorized {
+		t.Errorf("payment %s is %s, want %s authorized", authorized.ID, authorized.Status, payments[0].ID)
+	}
+}
+
+func TestPaymentWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     model.PaymentStatus
+		wantReason string
+	}{
+		{"authorized", model.PaymentAuthorized, ""},
+		{"declined", model.PaymentFailed, "card declined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			p, repo, fake := newTestPaymentUseCase()
+
+			pending, err := p.Authorize(ctx, "order-1", "", "", payment.FakeTokenPending)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pending.Status != model.PaymentPending {
+				t.Fatalf("payment is %s, want pending", pending.Status)
+			}
+
+			header, body, err := fake.Webhook(pending.Reference, tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The second delivery of the same callback changes nothing.
+			for range 2 {
+				if err = p.HandleWebhook(ctx, header, body); err != nil {
+					t.Fatal(err)
+				}
+				stored, err := repo.GetByID(ctx, pending.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if stored.Status != tt.status || stored.FailureReason != tt.wantReason {
+					t.Errorf("payment is %s with reason %q, want %s with %q", stored.Status, stored.FailureReason, tt.status, tt.wantReason)
+				}
+			}
+		})
+	}
+}
+
+func TestPaymentWebhookOutdated(t *testing.T) {
+	ctx := context.Background()
+	p, repo, fake := newTestPaymentUseCase()
+
+	pending, err := p.Authorize(ctx, "order-1", "", "", payment.FakeTokenPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, body, err := fake.Webhook(pending.Reference, model.PaymentAuthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.HandleWebhook(ctx, header, body); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Capture(ctx, pending.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second fake with the same secret signs an event the first one can no longer send,
+	// as a provider delivering callbacks out of order would.
+	other := payment.NewFake(testWebhookSecret)
+	if _, err = other.Authorize(ctx, payment.AuthorizeRequest{PaymentID: pending.ID, Token: payment.FakeTokenPending}); err != nil {
+		t.Fatal(err)
+	}
+	header, body, err = other.Webhook(pending.Reference, model.PaymentAuthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.HandleWebhook(ctx, header, body); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repo.GetByID(ctx, pending.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPayment(t, stored, model.PaymentCaptured, 1000, 0)
+}
+
+func TestPaymentWebhookRejected(t *testing.T) {
+	ctx := context.Background()
+	p, _, fake := newTestPaymentUseCase()
+
+	pending, err := p.Authorize(ctx, "order-1", "", "", payment.FakeTokenPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, body, err := fake.Webhook(pending.Reference, model.PaymentAuthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.HandleWebhook(ctx, header, append(body, ' ')); !errors.Is(err, payment.ErrInvalidSignature) {
+		t.Errorf("HandleWebhook with a changed body error = %v, want %v", err, payment.ErrInvalidSignature)
+	}
+
+	unknown := payment.NewFake(testWebhookSecret)
+	if _, err = unknown.Authorize(ctx, payment.AuthorizeRequest{PaymentID: "unknown", Token: payment.FakeTokenPending}); err != nil {
+		t.Fatal(err)
+	}
+	header, body, err = unknown.Webhook("fake_unknown", model.PaymentAuthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notFound *model.NotFoundError
+	if err = p.HandleWebhook(ctx, header, body); !errors.As(err, &notFound) {
+		t.Errorf("HandleWebhook for an unknown payment error = %v, want NotFoundError", err)
+	}
+}
+
+func TestPaymentPendingExpired(t *testing.T) {
+	ctx := context.Background()
+	p, repo, fake := newTestPaymentUseCase()
+
+	stale, err := p.Authorize(ctx, "order-1", "", "", payment.FakeTokenPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.age(stale.ID, model.PaymentPendingTTL)
+
+	// The stale payment no longer keeps the order from being paid.
+	paid, err := p.Authorize(ctx, "order-1", "", "", "tok_visa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.ID == stale.ID || paid.Status != model.PaymentAuthorized {
+		t.Fatalf("payment %s is %s, want a new authorized payment", paid.ID, paid.Status)
+	}
+	expired, err := repo.GetByID(ctx, stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired.Status != model.PaymentFailed || expired.FailureReason != model.PaymentExpiredReason {
+		t.Errorf("stale payment is %s with reason %q, want expired", expired.Status, expired.FailureReason)
+	}
+
+	// The provider authorizes the stale payment after all: the authorization is released.
+	header, body, err := fake.Webhook(stale.Reference, model.PaymentAuthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.HandleWebhook(ctx, header, body); err != nil {
+		t.Fatal(err)
+	}
+	if err = fake.Void(ctx, stale.Reference); err == nil {
+		t.Error("late authorization of an expired payment was not voided")
+	}
+	expired, err = repo.GetByID(ctx, stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired.Status != model.PaymentFailed {
+		t.Errorf("stale payment is %s, want failed", expired.Status)
+	}
+}
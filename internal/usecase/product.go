@@ -5,6 +5,7 @@ import (
 	"go.uber.org/zap"
 	"go_store/internal/model"
 	"go_store/internal/repository"
+	"slices"
 )
 
 var _ ProductUseCase = (*productUseCaseImpl)(nil)
@@ -21,18 +22,59 @@ func NewProductUseCase(logger *zap.Logger, productRepository repository.ProductR
 	}
 }
 
-func (p *productUseCaseImpl) Create(ctx context.Context, name string, description string, price int64) (string, error) {
-	return p.productRepository.Create(ctx, &model.Product{Name: name, Description: description, Price: price})
+func (p *productUseCaseImpl) Create(ctx context.Context, product *model.Product) (string, error) {
+	if product.TaxClass == "" {
+		product.TaxClass = model.DefaultTaxClass
+	}
+	return p.productRepository.Create(ctx, product)
 }
 
-func (p *productUseCaseImpl) Delete(ctx context.Context, id string) error {
-	return p.productRepository.Delete(ctx, id)
+func (p *productUseCaseImpl) Update(ctx context.Context, product *model.Product, fields []string) (*model.Product, error) {
+	if slices.Contains(fields, model.ProductFieldTaxClass) && product.TaxClass == "" {
+		return nil, &model.InvalidArgumentError{Field: "tax_class", Description: "must not be empty"}
+	}
+	return p.productRepository.Update(ctx, product, fields)
+}
+
+func (p *productUseCaseImpl) Archive(ctx context.Context, id string) error {
+	return p.productRepository.Archive(ctx, id)
+}
+
+func (p *productUseCaseImpl) Restore(ctx context.Context, id string) error {
+	return p.productRepository.Restore(ctx, id)
 }
 
 func (p *productUseCaseImpl) Get(ctx context.Context, id string) (*model.Product, error) {
 	return p.productRepository.GetByID(ctx, id)
 }
 
-func (p *productUseCaseImpl) List(ctx context.Context, limit, offset int32) ([]model.Product, error) {
-	return p.productRepository.List(ctx, limit, offset)
+func (p *productUseCaseImpl) List(ctx context.Context, filter model.ProductFilter, after *model.ProductCursor, limit, offset int32) ([]model.Product, *model.ProductCursor, error) {
+	limit = pageSize(limit)
+	products, err := p.productRepository.List(ctx, filter, after, limit+1, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(products) <= int(limit) {
+		return products, nil, nil
+	}
+
+	products = products[:limit]
+	last := products[len(products)-1]
+	return products, &model.ProductCursor{Name: last.Name, ID: last.ID}, nil
+}
+
+func (p *productUseCaseImpl) ListArchived(ctx context.Context, limit, offset int32) ([]model.Product, error) {
+	return p.productRepository.ListArchived(ctx, limit, offset)
+}
+
+func (p *productUseCaseImpl) SetStock(ctx context.Context, id string, stock int32) (int32, error) {
+	return p.productRepository.SetStock(ctx, id, stock)
+}
+
+func (p *productUseCaseImpl) AdjustStock(ctx context.Context, id string, delta int32) (int32, error) {
+	return p.productRepository.AdjustStock(ctx, id, delta)
+}
+
+func (p *productUseCaseImpl) Search(ctx context.Context, query string, limit, offset int32) ([]model.ProductSearchResult, error) {
+	return p.productRepository.Search(ctx, query, limit, offset)
 }
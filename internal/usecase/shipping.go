@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+	"slices"
+	"strings"
+)
+
+var _ ShippingUseCase = (*shippingUseCaseImpl)(nil)
+
+type shippingUseCaseImpl struct {
+	logger             *zap.Logger
+	shippingRepository repository.ShippingRepository
+	productRepository  repository.ProductRepository
+}
+
+func NewShippingUseCase(logger *zap.Logger, shippingRepository repository.ShippingRepository, productRepository repository.ProductRepository) ShippingUseCase {
+	return &shippingUseCaseImpl{
+		logger:             logger,
+		shippingRepository: shippingRepository,
+		productRepository:  productRepository,
+	}
+}
+
+func (s *shippingUseCaseImpl) CreateMethod(ctx context.Context, method *model.ShippingMethod) (string, error) {
+	switch method.RateType {
+	case model.ShippingRateFlat:
+		method.Rates = nil
+	case model.ShippingRateWeight, model.ShippingRateOrderValue:
+		if len(method.Rates) == 0 {
+			return "", &model.InvalidArgumentError{Field: "rates", Description: "are required for weight and order value rates"}
+		}
+		method.FlatRate = 0
+	default:
+		return "", &model.InvalidArgumentError{Field: "rate_type", Description: "unknown rate type"}
+	}
+
+	slices.SortFunc(method.Rates, func(a, b model.ShippingRate) int {
+		return cmp.Compare(a.MinValue, b.MinValue)
+	})
+	for i := 1; i < len(method.Rates); i++ {
+		if method.Rates[i].MinValue == method.Rates[i-1].MinValue {
+			return "", &model.InvalidArgumentError{Field: "rates.min_value", Description: "must be unique"}
+		}
+	}
+	for i, country := range method.Countries {
+		method.Countries[i] = strings.ToUpper(country)
+	}
+
+	id, err := s.shippingRepository.CreateMethod(ctx, method)
+	if err != nil {
+		return "", err
+	}
+	s.logger.Info("shipping method created", zap.String("id", id), zap.String("name", method.Name))
+	return id, nil
+}
+
+func (s *shippingUseCaseImpl) ListMethods(ctx context.Context, includeDisabled bool) ([]model.ShippingMethod, error) {
+	return s.shippingRepository.ListMethods(ctx, includeDisabled)
+}
+
+func (s *shippingUseCaseImpl) DisableMethod(ctx context.Context, id string) error {
+	return s.shippingRepository.DisableMethod(ctx, id)
+}
+
+func (s *shippingUseCaseImpl) Quote(ctx context.Context, items []model.OrderItem, address model.Address) ([]model.ShippingQuote, error) {
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := s.productRepository.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	order := model.Order{Items: slices.Clone(items)}
+	for i := range order.Items {
+		product, ok := byID[order.Items[i].ProductID]
+		if !ok {
+			return nil, &model.InvalidReferenceError{Field: "items.product_id", Entity: "product", ID: order.Items[i].ProductID}
+		}
+		order.Items[i].UnitPrice = product.Price
+		order.Items[i].UnitWeight = product.Weight
+	}
+	order.CalculateTotals()
+
+	methods, err := s.shippingRepository.ListMethods(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	quotes := []model.ShippingQuote{}
+	for _, method := range methods {
+		if cost, ok := method.Cost(address.Country, order.Subtotal, order.Weight()); ok {
+			quotes = append(quotes, model.ShippingQuote{Method: method, Cost: cost})
+		}
+	}
+	slices.SortStableFunc(quotes, func(a, b model.ShippingQuote) int {
+		return cmp.Compare(a.Cost, b.Cost)
+	})
+	return quotes, nil
+}
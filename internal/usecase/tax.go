@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go_store/internal/model"
+	"go_store/internal/repository"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var _ TaxUseCase = (*taxUseCaseImpl)(nil)
+
+type taxUseCaseImpl struct {
+	logger        *zap.Logger
+	taxRepository repository.TaxRepository
+}
+
+func NewTaxUseCase(logger *zap.Logger, taxRepository repository.TaxRepository) TaxUseCase {
+	return &taxUseCaseImpl{
+		logger:        logger,
+		taxRepository: taxRepository,
+	}
+}
+
+func (t *taxUseCaseImpl) CreateRule(ctx context.Context, rule *model.TaxRule) (string, error) {
+	rule.Country = strings.ToUpper(rule.Country)
+	rule.Region = strings.ToUpper(rule.Region)
+	return t.taxRepository.CreateRule(ctx, rule)
+}
+
+func (t *taxUseCaseImpl) ListRules(ctx context.Context, country string) ([]model.TaxRule, error) {
+	return t.taxRepository.ListRules(ctx, strings.ToUpper(country))
+}
+
+func (t *taxUseCaseImpl) DeleteRule(ctx context.Context, id string) error {
+	return t.taxRepository.DeleteRule(ctx, id)
+}
+
+// taxCalculator applies the tax rules of one address to orders. For every tax class
+// a rule of the region takes precedence over the rule of the whole country.
+type taxCalculator struct {
+	address model.Address
+	rules   map[string]model.TaxRule
+}
+
+func newTaxCalculator(address model.Address, rules []model.TaxRule) *taxCalculator {
+	byClass := make(map[string]model.TaxRule)
+	for _, rule := range rules {
+		if rule.Country != address.Country || (rule.Region != "" && rule.Region != address.Region) {
+			continue
+		}
+		if current, ok := byClass[rule.TaxClass]; ok && current.Region != "" {
+			continue
+		}
+		byClass[rule.TaxClass] = rule
+	}
+	return &taxCalculator{address: address, rules: byClass}
+}
+
+// Apply sets the tax lines and totals of the order. The discount is split between tax classes
+// in proportion to their amounts, so only what the customer pays is taxed.
+func (t *taxCalculator) Apply(order *model.Order) error {
+	amounts := make(map[string]int64)
+	for _, item := range order.Items {
+		amounts[item.TaxClass] += item.LineTotal
+	}
+	classes := slices.Sorted(maps.Keys(amounts))
+
+	order.TaxCountry = t.address.Country
+	order.TaxRegion = t.address.Region
+	order.TaxLines = nil
+	remaining := order.Discount
+	for i, class := range classes {
+		share := remaining
+		if i < len(classes)-1 && order.Subtotal > 0 {
+			share = order.Discount * amounts[class] / order.Subtotal
+		}
+		remaining -= share
+
+		rule, ok := t.rules[class]
+		taxable := amounts[class] - share
+		if !ok || taxable <= 0 {
+			continue
+		}
+		order.TaxLines = append(order.TaxLines, model.TaxLine{
+			TaxClass:      class,
+			Name:          rule.Name,
+			Rate:          rule.Rate,
+			Inclusive:     rule.Inclusive,
+			TaxableAmount: taxable,
+			Amount:        taxAmount(taxable, rule.Rate, rule.Inclusive),
+		})
+	}
+	order.CalculateTotals()
+	return nil
+}
+
+// taxAmount returns the tax for the amount, rounded half up. rate is in basis points.
+// Inclusive taxes are extracted from the amount, exclusive ones are charged on top of it.
+func taxAmount(amount int64, rate int32, inclusive bool) int64 {
+	r := int64(rate)
+	if inclusive {
+		return (2*amount*r + 10000 + r) / (2 * (10000 + r))
+	}
+	return (amount*r + 5000) / 10000
+}
@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"go_store/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestTaxAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int64
+		rate      int32
+		inclusive bool
+		want      int64
+	}{
+		{"exclusive exact", 2000, 1900, false, 380},
+		{"exclusive rounds down below half", 24, 1000, false, 2},
+		{"exclusive rounds half up", 25, 1000, false, 3},
+		{"exclusive half of the smallest unit", 5, 1000, false, 1},
+		{"exclusive zero rate", 1000, 0, false, 0},
+		{"inclusive exact", 1200, 2000, true, 200},
+		{"inclusive rounds up above half", 1210, 2000, true, 202},
+		{"inclusive rounds down below half", 8, 2000, true, 1},
+		{"inclusive rounds half up", 9, 2000, true, 2},
+		{"inclusive half of the smallest unit", 3, 2000, true, 1},
+		{"inclusive zero rate", 1000, 0, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taxAmount(tt.amount, tt.rate, tt.inclusive); got != tt.want {
+				t.Errorf("taxAmount(%d, %d, %v) = %d, want %d", tt.amount, tt.rate, tt.inclusive, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaxCalculatorApply(t *testing.T) {
+	standard := model.TaxRule{Country: "DE", TaxClass: model.DefaultTaxClass, Name: "VAT", Rate: 1900}
+	reduced := model.TaxRule{Country: "DE", TaxClass: "books", Name: "Reduced VAT", Rate: 700}
+	bavaria := model.TaxRule{Country: "DE", Region: "BY", TaxClass: model.DefaultTaxClass, Name: "Bavarian VAT", Rate: 1000}
+	inclusive := model.TaxRule{Country: "DE", TaxClass: model.DefaultTaxClass, Name: "VAT", Rate: 2000, Inclusive: true}
+
+	tests := []struct {
+		name      string
+		address   model.Address
+		rules     []model.TaxRule
+		items     []model.OrderItem
+		discount  int64
+		wantLines []model.TaxLine
+		wantTax   int64
+		wantTotal int64
+	}{
+		{
+			name:    "exclusive tax is added to the total",
+			address: model.Address{Country: "DE"},
+			rules:   []model.TaxRule{standard},
+			items:   []model.OrderItem{{UnitPrice: 1000, Quantity: 2, TaxClass: model.DefaultTaxClass}},
+			wantLines: []model.TaxLine{
+				{TaxClass: model.DefaultTaxClass, Name: "VAT", Rate: 1900, TaxableAmount: 2000, Amount: 380},
+			},
+			wantTax:   380,
+			wantTotal: 2380,
+		},
+		{
+			name:    "inclusive tax is part of the total",
+			address: model.Address{Country: "DE"},
+			rules:   []model.TaxRule{inclusive},
+			items:   []model.OrderItem{{UnitPrice: 1210, Quantity: 1, TaxClass: model.DefaultTaxClass}},
+			wantLines: []model.TaxLine{
+				{TaxClass: model.DefaultTaxClass, Name: "VAT", Rate: 2000, Inclusive: true, TaxableAmount: 1210, Amount: 202},
+			},
+			wantTax:   202,
+			wantTotal: 1210,
+		},
+		{
+			name:    "discount is split between classes in proportion",
+			address: model.Address{Country: "DE"},
+			rules:   []model.TaxRule{standard, reduced},
+			items: []model.OrderItem{
+				{UnitPrice: 1000, Quantity: 1, TaxClass: model.DefaultTaxClass},
+				{UnitPrice: 500, Quantity: 1, TaxClass: "books"},
+			},
+			discount: 300,
+			wantLines: []model.TaxLine{
+				{TaxClass: "books", Name: "Reduced VAT", Rate: 700, TaxableAmount: 400, Amount: 28},
+				{TaxClass: model.DefaultTaxClass, Name: "VAT", Rate: 1900, TaxableAmount: 800, Amount: 152},
+			},
+			wantTax:   180,
+			wantTotal: 1380,
+		},
+		{
+			name:    "discount larger than one class",
+			address: model.Address{Country: "DE"},
+			rules:   []model.TaxRule{standard, reduced},
+			items: []model.OrderItem{
+				{UnitPrice: 1000, Quantity: 1, TaxClass: model.DefaultTaxClass},
+				{UnitPrice: 100, Quantity: 1, TaxClass: "books"},
+			},
+			discount: 800,
+			wantLines: []model.TaxLine{
+				{TaxClass: "books", Name: "Reduced VAT", Rate: 700, TaxableAmount: 28, Amount: 2},
+				{TaxClass: model.DefaultTaxClass, Name: "VAT", Rate: 1900, TaxableAmount: 272, Amount: 52},
+			},
+			wantTax:   54,
+			wantTotal: 354,
+		},
+		{
+			name:    "discount of the whole subtotal leaves nothing to tax",
+			address: model.Address{Country: "DE"},
+			rules:   []model.TaxRule{standard, reduced},
+			items: []model.OrderItem{
+				{UnitPrice: 1000, Quantity: 1, TaxClass: model.DefaultTaxClass},
+				{UnitPrice: 100, Quantity: 1, TaxClass: "books"},
+			},
+			discount:  1100,
+			wantTotal: 0,
+		},
+		{
+			name:    "region rule overrides the country rule",
+			address: model.Address{Country: "DE", Region: "BY"},
+			rules:   []model.TaxRule{standard, bavaria},
+			items:   []model.OrderItem{{UnitPrice: 1000, Quantity: 1, TaxClass: model.DefaultTaxClass}},
+			wantLines: []model.TaxLine{
+				{TaxClass: model.DefaultTaxClass, Name: "Bavarian VAT", Rate: 1000, TaxableAmount: 1000, Amount: 100},
+			},
+			wantTax:   100,
+			wantTotal: 1100,
+		},
+		{
+			name:    "region rule listed first still overrides the country rule",
+			address: model.Address{Country: "DE", Region: "BY"},
+			rules:   []model.TaxRule{bavaria, standard},
+			items:   []model.OrderItem{{UnitPrice: 1000, Quantity: 1, TaxClass: model.DefaultTaxClass}},
+			wantLines: []model.TaxLine{
+				{TaxClass: model.DefaultTaxClass, Name: "Bavarian VAT", Rate: 1000, TaxableAmount: 1000, Amount: 100},
+			},
+			wantTax:   100,
+			wantTotal: 1100,
+		},
+		{
+			name:    "rule of another region is ignored",
+			address: model.Address{Country: "DE", Region: "BE"},
+			rules:   []model.TaxRule{bavaria, standard},
+			items:   []model.OrderItem{{UnitPrice: 1000, Quantity: 1, TaxClass: model.DefaultTaxClass}},
+			wantLines: []model.TaxLine{
+				{TaxClass: model.DefaultTaxClass, Name: "VAT", Rate: 1900, TaxableAmount: 1000, Amount: 190},
+			},
+			wantTax:   190,
+			wantTotal: 1190,
+		},
+		{
+			name:    "class without a rule is not taxed",
+			address: model.Address{Country: "DE"},
+			rules:   []model.TaxRule{standard},
+			items: []model.OrderItem{
+				{UnitPrice: 1000, Quantity: 1, TaxClass: model.DefaultTaxClass},
+				{UnitPrice: 500, Quantity: 1, TaxClass: "books"},
+			},
+			wantLines: []model.TaxLine{
+				{TaxClass: model.DefaultTaxClass, Name: "VAT", Rate: 1900, TaxableAmount: 1000, Amount: 190},
+			},
+			wantTax:   190,
+			wantTotal: 1690,
+		},
+		{
+			name:      "rules of another country are ignored",
+			address:   model.Address{Country: "FR"},
+			rules:     []model.TaxRule{standard, reduced},
+			items:     []model.OrderItem{{UnitPrice: 1000, Quantity: 1, TaxClass: model.DefaultTaxClass}},
+			wantTotal: 1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &model.Order{Items: tt.items, Discount: tt.discount}
+			order.CalculateTotals()
+
+			if err := newTaxCalculator(tt.address, tt.rules).Apply(order); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(order.TaxLines, tt.wantLines) {
+				t.Errorf("tax lines = %+v, want %+v", order.TaxLines, tt.wantLines)
+			}
+			if order.Tax != tt.wantTax || order.Total != tt.wantTotal {
+				t.Errorf("tax, total = %d, %d, want %d, %d", order.Tax, order.Total, tt.wantTax, tt.wantTotal)
+			}
+			if order.TaxCountry != tt.address.Country || order.TaxRegion != tt.address.Region {
+				t.Errorf("tax address = %s/%s, want %s/%s", order.TaxCountry, order.TaxRegion, tt.address.Country, tt.address.Region)
+			}
+		})
+	}
+}